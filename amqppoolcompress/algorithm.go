@@ -0,0 +1,163 @@
+package amqppoolcompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+//ErrTooLarge the body decompressed exceeds the maximum size
+var ErrTooLarge = errors.New("the body decompressed exceeds the maximum size")
+
+//Algorithm compress and decompress the bodies in a content encoding
+type Algorithm interface {
+	Encoding() string                                    //the content encoding of the bodies compressed, e.g. gzip
+	Compress(body []byte) ([]byte, error)                //compress a body
+	Decompress(body []byte, maxSize int) ([]byte, error) //decompress a body, failing with ErrTooLarge above maxSize
+}
+
+//The algorithms of compression built in, zstd compress the most, snappy is the fastest and gzip is the most supported
+var (
+	Gzip   Algorithm = gzipAlgorithm{}
+	Zstd   Algorithm = &zstdAlgorithm{}
+	Snappy Algorithm = snappyAlgorithm{}
+)
+
+//gzipAlgorithm compress the bodies with gzip
+type gzipAlgorithm struct{}
+
+//Encoding get the content encoding gzip
+func (gzipAlgorithm) Encoding() string {
+	return "gzip"
+}
+
+//Compress compress a body with gzip
+func (gzipAlgorithm) Compress(body []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(body); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return compressed.Bytes(), nil
+}
+
+//Decompress decompress a body with gzip
+func (gzipAlgorithm) Decompress(body []byte, maxSize int) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	decompressed, err := ioutil.ReadAll(io.LimitReader(reader, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(decompressed) > maxSize {
+		return nil, ErrTooLarge
+	}
+
+	return decompressed, nil
+}
+
+//zstdAlgorithm compress the bodies with zstd, sharing the encoder and the decoders which are safe to concurrent use
+type zstdAlgorithm struct {
+	once     sync.Once     //create the encoder in the first use
+	encoder  *zstd.Encoder //compress the bodies
+	err      error         //the error to create the encoder
+	decoders sync.Map      //the *zstd.Decoder which decompress the bodies by their maximum size
+}
+
+//Encoding get the content encoding zstd
+func (*zstdAlgorithm) Encoding() string {
+	return "zstd"
+}
+
+//Compress compress a body with zstd
+func (algorithm *zstdAlgorithm) Compress(body []byte) ([]byte, error) {
+	algorithm.once.Do(func() {
+		algorithm.encoder, algorithm.err = zstd.NewWriter(nil)
+	})
+	if algorithm.err != nil {
+		return nil, algorithm.err
+	}
+
+	return algorithm.encoder.EncodeAll(body, nil), nil
+}
+
+//Decompress decompress a body with zstd
+func (algorithm *zstdAlgorithm) Decompress(body []byte, maxSize int) ([]byte, error) {
+	decoder, err := algorithm.decoder(maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	decompressed, err := decoder.DecodeAll(body, nil)
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) || len(decompressed) > maxSize {
+		return nil, ErrTooLarge
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return decompressed, nil
+}
+
+//decoder get the decoder limited to the maximum size, creating it in the first use
+func (algorithm *zstdAlgorithm) decoder(maxSize int) (*zstd.Decoder, error) {
+	if decoder, exist := algorithm.decoders.Load(maxSize); exist {
+		return decoder.(*zstd.Decoder), nil
+	}
+
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(uint64(maxSize)))
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, loaded := algorithm.decoders.LoadOrStore(maxSize, decoder); loaded {
+		decoder.Close()
+		return existing.(*zstd.Decoder), nil
+	}
+
+	return decoder, nil
+}
+
+//snappyAlgorithm compress the bodies with the block format of snappy
+type snappyAlgorithm struct{}
+
+//Encoding get the content encoding snappy
+func (snappyAlgorithm) Encoding() string {
+	return "snappy"
+}
+
+//Compress compress a body with snappy
+func (snappyAlgorithm) Compress(body []byte) ([]byte, error) {
+	return snappy.Encode(nil, body), nil
+}
+
+//Decompress decompress a body with snappy
+func (snappyAlgorithm) Decompress(body []byte, maxSize int) ([]byte, error) {
+	size, err := snappy.DecodedLen(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if size > maxSize {
+		return nil, ErrTooLarge
+	}
+
+	return snappy.Decode(nil, body)
+}
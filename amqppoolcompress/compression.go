@@ -0,0 +1,157 @@
+//Package amqppoolcompress compress the bodies of the messages above a size threshold, setting their ContentEncoding,
+//and decompress the deliveries by their ContentEncoding:
+//
+//	compression := amqppoolcompress.NewCompression(amqppoolcompress.Zstd, amqppoolcompress.WithThreshold(4096))
+//	err := compression.Publish(reusableChannel, "orders", "order.created", false, false, publishing)
+//
+//	consumer := amqppool.NewConsumer[Order](pool, "orders", amqppool.WithDeliveryMiddleware(compression.Decompress))
+//
+//Compression.Publish publish through one Publisher. To compress every publish of a pool, including the typed
+//publishes of amqppool.Publish, the compression is added to the publish middlewares of the pool:
+//
+//	pool, err := amqppool.NewPool(connectionString, 10,
+//		amqppool.WithPublishMiddleware(amqppool.TransformPublishing(compression.Compress)))
+package amqppoolcompress
+
+import (
+	"fmt"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/streadway/amqp"
+)
+
+//The defaults of the sizes of the bodies, in bytes
+const (
+	DefaultThreshold = 1024      //the size from which the bodies are compressed
+	DefaultMaxSize   = 128 << 20 //the maximum size of the bodies decompressed, the maximum size of message of RabbitMQ
+)
+
+//Publisher publish messages, implemented by amqppool.ReusableChannel and amqp.Channel
+type Publisher interface {
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
+//Compression compress the bodies of the publishings and decompress the bodies of the deliveries
+type Compression struct {
+	algorithm  Algorithm            //compress the bodies of the publishings
+	threshold  int                  //the size of the bodies from which they are compressed
+	maxSize    int                  //the maximum size of the bodies decompressed
+	algorithms map[string]Algorithm //decompress the bodies of the deliveries by their encoding
+}
+
+var _ amqppool.DeliveryMiddleware = (*Compression)(nil).Decompress
+
+//Option configure a Compression in its creation
+type Option func(config *compressionConfig)
+
+//compressionConfig the configuration of a Compression
+type compressionConfig struct {
+	threshold  int
+	maxSize    int
+	algorithms []Algorithm
+}
+
+//WithThreshold configure the size of the bodies, in bytes, from which they are compressed, by default
+//DefaultThreshold. The small bodies are published uncompressed because the compression don't pay its cost
+func WithThreshold(threshold int) Option {
+	return func(config *compressionConfig) {
+		config.threshold = threshold
+	}
+}
+
+//WithMaxSize configure the maximum size of the bodies decompressed, in bytes, DefaultMaxSize by default or when it
+//is not positive. The deliveries larger fail with ErrTooLarge, to a small body compressed don't exhaust the memory
+//of the consumer
+func WithMaxSize(maxSize int) Option {
+	return func(config *compressionConfig) {
+		config.maxSize = maxSize
+	}
+}
+
+//WithAlgorithms configure the algorithms accepted to decompress the deliveries, besides the algorithm of the
+//compression, by default Gzip, Zstd and Snappy
+func WithAlgorithms(algorithms ...Algorithm) Option {
+	return func(config *compressionConfig) {
+		config.algorithms = algorithms
+	}
+}
+
+//NewCompression create a new Compression which compress the publishings with the algorithm
+func NewCompression(algorithm Algorithm, options ...Option) *Compression {
+	config := &compressionConfig{
+		threshold:  DefaultThreshold,
+		maxSize:    DefaultMaxSize,
+		algorithms: []Algorithm{Gzip, Zstd, Snappy},
+	}
+
+	for _, option := range options {
+		option(config)
+	}
+
+	if config.maxSize <= 0 {
+		config.maxSize = DefaultMaxSize
+	}
+
+	algorithms := make(map[string]Algorithm, len(config.algorithms)+1)
+	for _, accepted := range config.algorithms {
+		algorithms[accepted.Encoding()] = accepted
+	}
+	algorithms[algorithm.Encoding()] = algorithm
+
+	return &Compression{
+		algorithm:  algorithm,
+		threshold:  config.threshold,
+		maxSize:    config.maxSize,
+		algorithms: algorithms,
+	}
+}
+
+//Compress compress the body of a publishing when it reaches the threshold, setting its ContentEncoding. The
+//publishings already encoded are kept how they are
+func (compression *Compression) Compress(msg amqp.Publishing) (amqp.Publishing, error) {
+	if len(msg.Body) < compression.threshold || msg.ContentEncoding != "" {
+		return msg, nil
+	}
+
+	body, err := compression.algorithm.Compress(msg.Body)
+	if err != nil {
+		return msg, fmt.Errorf("failed to compress the body in %v: %w", compression.algorithm.Encoding(), err)
+	}
+
+	msg.Body = body
+	msg.ContentEncoding = compression.algorithm.Encoding()
+	return msg, nil
+}
+
+//Publish compress the body of a message when it reaches the threshold and publish it
+func (compression *Compression) Publish(publisher Publisher, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	msg, err := compression.Compress(msg)
+	if err != nil {
+		return err
+	}
+
+	return publisher.Publish(exchange, key, mandatory, immediate, msg)
+}
+
+//Decompress decompress the body of a delivery by its ContentEncoding, clearing it. The deliveries without
+//ContentEncoding are kept how they are, the deliveries of an encoding not accepted or larger than the maximum size
+//fail
+func (compression *Compression) Decompress(delivery amqp.Delivery) (amqp.Delivery, error) {
+	if delivery.ContentEncoding == "" {
+		return delivery, nil
+	}
+
+	algorithm, accepted := compression.algorithms[delivery.ContentEncoding]
+	if !accepted {
+		return delivery, fmt.Errorf("the content encoding %q is not accepted", delivery.ContentEncoding)
+	}
+
+	body, err := algorithm.Decompress(delivery.Body, compression.maxSize)
+	if err != nil {
+		return delivery, fmt.Errorf("failed to decompress the body in %v: %w", delivery.ContentEncoding, err)
+	}
+
+	delivery.Body = body
+	delivery.ContentEncoding = ""
+	return delivery, nil
+}
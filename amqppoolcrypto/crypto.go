@@ -0,0 +1,393 @@
+//Package amqppoolcrypto encrypt the bodies of the messages with AES-GCM and sign their headers, properties and body
+//with HMAC-SHA256, rejecting the deliveries tampered or which can't be decrypted:
+//
+//	crypto := amqppoolcrypto.NewCrypto(amqppoolcrypto.NewKeys("2024-01", key),
+//		amqppoolcrypto.WithSigning(amqppoolcrypto.NewKeys("2024-01", signingKey)))
+//	err := crypto.Publish(reusableChannel, "customers", "customer.created", false, false, publishing)
+//
+//	consumer := amqppool.NewConsumer[Customer](pool, "customers", amqppool.WithDeliveryMiddleware(crypto.Open))
+//
+//The ids of the keys are published in the headers, so the deliveries sealed before a rotation are still opened. Only
+//the headers present when sealed are verified, so the headers added by the broker, how x-death, don't reject them.
+//With compression, the bodies must be compressed before sealed and opened before decompressed.
+package amqppoolcrypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/streadway/amqp"
+)
+
+//The headers of the messages sealed
+const (
+	EncryptionKeyHeader = "x-encryption-key-id" //the id of the key of encryption of the body
+	SigningKeyHeader    = "x-signing-key-id"    //the id of the key of the signature
+	SignatureHeader     = "x-signature"         //the signature in base64 of the headers and the body
+	SignedHeadersHeader = "x-signed-headers"    //the names of the headers signed, separated by commas
+)
+
+//brokerHeaders the headers added by the broker to the deliveries after they are sealed, how when dead lettered or
+//redelivered by a quorum queue, which are kept unsigned when opened
+var brokerHeaders = map[string]bool{"x-death": true, "x-delivery-count": true}
+
+//brokerHeaderPrefixes the prefixes of the headers added by the broker when the deliveries are dead lettered
+var brokerHeaderPrefixes = []string{"x-first-death-", "x-last-death-"}
+
+var (
+	//ErrUndecryptable the body of the delivery isn't encrypted or can't be decrypted
+	ErrUndecryptable = errors.New("the delivery can't be decrypted")
+	//ErrTampered the delivery isn't signed or its signature don't match its headers and body
+	ErrTampered = errors.New("the delivery was tampered")
+)
+
+//Publisher publish messages, implemented by amqppool.ReusableChannel and amqp.Channel
+type Publisher interface {
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
+//Crypto encrypt and sign the publishings and decrypt and verify the deliveries
+type Crypto struct {
+	encryption KeyProvider //the keys of encryption of the bodies, nil to only sign
+	signing    KeyProvider //the keys of signature, nil to don't sign
+}
+
+var _ amqppool.DeliveryMiddleware = (*Crypto)(nil).Open
+
+//Option configure a Crypto in its creation
+type Option func(crypto *Crypto)
+
+//WithSigning sign the headers, the properties and the body of the publishings with HMAC-SHA256 and reject the
+//deliveries without a valid signature
+func WithSigning(keys KeyProvider) Option {
+	return func(crypto *Crypto) {
+		crypto.signing = keys
+	}
+}
+
+//NewCrypto create a new Crypto which encrypt the bodies with the keys of AES of 16, 24 or 32 bytes, or nil to only
+//sign them
+func NewCrypto(keys KeyProvider, options ...Option) *Crypto {
+	crypto := &Crypto{encryption: keys}
+	for _, option := range options {
+		option(crypto)
+	}
+
+	return crypto
+}
+
+//Seal encrypt the body of a publishing with the current key and sign it, setting the ids of the keys in its headers
+func (crypto *Crypto) Seal(msg amqp.Publishing) (amqp.Publishing, error) {
+	headers := make(amqp.Table, len(msg.Headers)+3)
+	for name, value := range msg.Headers {
+		headers[name] = value
+	}
+	msg.Headers = headers
+
+	if crypto.encryption != nil {
+		id, key, err := crypto.encryption.Current()
+		if err != nil {
+			return msg, fmt.Errorf("failed to get the key of encryption: %w", err)
+		}
+
+		body, err := encrypt(key, id, msg.Body)
+		if err != nil {
+			return msg, fmt.Errorf("failed to encrypt the body with the key %q: %w", id, err)
+		}
+
+		msg.Body = body
+		headers[EncryptionKeyHeader] = id
+	}
+
+	if crypto.signing != nil {
+		id, key, err := crypto.signing.Current()
+		if err != nil {
+			return msg, fmt.Errorf("failed to get the key of signature: %w", err)
+		}
+
+		headers[SigningKeyHeader] = id
+		names := make([]string, 0, len(headers))
+		for name := range headers {
+			if strings.Contains(name, ",") {
+				return msg, fmt.Errorf("failed to sign the header %q, the names with commas can't be signed", name)
+			}
+
+			if name != SignatureHeader && name != SignedHeadersHeader {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		properties := signedProperties(msg.ContentType, msg.ContentEncoding, msg.ReplyTo, msg.CorrelationId,
+			msg.MessageId, msg.Type, msg.AppId)
+		headers[SignedHeadersHeader] = strings.Join(names, ",")
+		headers[SignatureHeader] = base64.StdEncoding.EncodeToString(sign(key, properties, names, headers, msg.Body))
+	}
+
+	return msg, nil
+}
+
+//Publish seal a message and publish it
+func (crypto *Crypto) Publish(publisher Publisher, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	msg, err := crypto.Seal(msg)
+	if err != nil {
+		return err
+	}
+
+	return publisher.Publish(exchange, key, mandatory, immediate, msg)
+}
+
+//Open verify the signature of a delivery and decrypt its body by the ids of the keys in its headers. The deliveries
+//not sealed how expected fail with ErrTampered or ErrUndecryptable, to don't be downgraded to plain text. With
+//signing, the headers not signed are removed, except the added by the broker how x-death and x-delivery-count
+func (crypto *Crypto) Open(delivery amqp.Delivery) (amqp.Delivery, error) {
+	if crypto.signing != nil {
+		headers, err := crypto.verify(delivery)
+		if err != nil {
+			return delivery, fmt.Errorf("%w: %v", ErrTampered, err)
+		}
+		delivery.Headers = headers
+	}
+
+	if crypto.encryption == nil {
+		return delivery, nil
+	}
+
+	id, encrypted := delivery.Headers[EncryptionKeyHeader].(string)
+	if !encrypted {
+		return delivery, fmt.Errorf("%w: the header %v was not found", ErrUndecryptable, EncryptionKeyHeader)
+	}
+
+	key, err := crypto.encryption.Key(id)
+	if err != nil {
+		return delivery, fmt.Errorf("%w: %v", ErrUndecryptable, err)
+	}
+
+	body, err := decrypt(key, id, delivery.Body)
+	if err != nil {
+		return delivery, fmt.Errorf("%w with the key %q: %v", ErrUndecryptable, id, err)
+	}
+
+	delivery.Body = body
+	return delivery, nil
+}
+
+//verify verify the signature of the headers signed, the properties and the body of a delivery, getting its headers
+//signed and added by the broker
+func (crypto *Crypto) verify(delivery amqp.Delivery) (amqp.Table, error) {
+	id, signed := delivery.Headers[SigningKeyHeader].(string)
+	encoded, hasSignature := delivery.Headers[SignatureHeader].(string)
+	list, hasNames := delivery.Headers[SignedHeadersHeader].(string)
+	if !signed || !hasSignature || !hasNames {
+		return nil, fmt.Errorf("the headers %v, %v and %v were not found", SigningKeyHeader, SignatureHeader,
+			SignedHeadersHeader)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("the signature is malformed: %v", err)
+	}
+
+	key, err := crypto.signing.Key(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if list != "" {
+		names = strings.Split(list, ",")
+	}
+
+	properties := signedProperties(delivery.ContentType, delivery.ContentEncoding, delivery.ReplyTo,
+		delivery.CorrelationId, delivery.MessageId, delivery.Type, delivery.AppId)
+	if !hmac.Equal(signature, sign(key, properties, names, delivery.Headers, delivery.Body)) {
+		return nil, errors.New("the signature don't match")
+	}
+
+	headers := make(amqp.Table, len(names)+2)
+	for _, name := range names {
+		if value, exist := delivery.Headers[name]; exist {
+			headers[name] = value
+		}
+	}
+	headers[SignatureHeader] = encoded
+	headers[SignedHeadersHeader] = list
+
+	for name, value := range delivery.Headers {
+		if addedByBroker(name) {
+			headers[name] = value
+		}
+	}
+
+	return headers, nil
+}
+
+//addedByBroker indicates if a header is added by the broker to the deliveries
+func addedByBroker(name string) bool {
+	if brokerHeaders[name] {
+		return true
+	}
+
+	for _, prefix := range brokerHeaderPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//encrypt encrypt a body with AES-GCM, prefixing the random nonce and authenticating the id of the key
+func encrypt(key []byte, id string, body []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(body)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, body, []byte(id)), nil
+}
+
+//decrypt decrypt a body encrypted by encrypt
+func decrypt(key []byte, id string, body []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) < aead.NonceSize() {
+		return nil, errors.New("the body is shorter than the nonce")
+	}
+
+	return aead.Open(nil, body[:aead.NonceSize()], body[aead.NonceSize():], []byte(id))
+}
+
+//newAEAD create the AES-GCM of a key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+//signedProperties the properties which route or describe a message, in the order signed. The ReplyTo of
+//DirectReplyTo is signed without the suffix of the channel which the broker add when it's published
+func signedProperties(contentType, contentEncoding, replyTo, correlationID, messageID, kind, appID string) []string {
+	if strings.HasPrefix(replyTo, amqppool.DirectReplyTo) {
+		replyTo = amqppool.DirectReplyTo
+	}
+
+	return []string{contentType, contentEncoding, replyTo, correlationID, messageID, kind, appID}
+}
+
+//sign create the HMAC-SHA256 of the properties, the headers of the names and the body, encoded with the length of
+//each one to don't be ambiguous
+func sign(key []byte, properties, names []string, headers amqp.Table, body []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	for _, property := range properties {
+		writeString(mac, property)
+	}
+
+	_ = binary.Write(mac, binary.BigEndian, uint32(len(names)))
+	for _, name := range names {
+		writeString(mac, name)
+		writeValue(mac, headers[name])
+	}
+	_, _ = mac.Write(body)
+
+	return mac.Sum(nil)
+}
+
+//writeString write a string prefixed by its length
+func writeString(w io.Writer, value string) {
+	_ = binary.Write(w, binary.BigEndian, uint32(len(value)))
+	_, _ = io.WriteString(w, value)
+}
+
+//writeValue write a value of a header with the tag of its type and its value in binary, how the field tables of
+//AMQP, so the values are signed how they are received: the ints how int32, the times in seconds and the tables in
+//the order of their names. The headers missing are written how nil
+func writeValue(w io.Writer, value interface{}) {
+	switch value := value.(type) {
+	case nil:
+		_, _ = w.Write([]byte{'V'})
+	case bool:
+		tagged := []byte{'t', 0}
+		if value {
+			tagged[1] = 1
+		}
+		_, _ = w.Write(tagged)
+	case byte:
+		_, _ = w.Write([]byte{'b', value})
+	case int16:
+		writeTagged(w, 's', value)
+	case int:
+		writeTagged(w, 'I', int32(value))
+	case int32:
+		writeTagged(w, 'I', value)
+	case int64:
+		writeTagged(w, 'l', value)
+	case float32:
+		writeTagged(w, 'f', math.Float32bits(value))
+	case float64:
+		writeTagged(w, 'd', math.Float64bits(value))
+	case amqp.Decimal:
+		writeTagged(w, 'D', struct {
+			Scale uint8
+			Value int32
+		}{value.Scale, value.Value})
+	case string:
+		_, _ = w.Write([]byte{'S'})
+		writeString(w, value)
+	case []byte:
+		_, _ = w.Write([]byte{'x'})
+		writeString(w, string(value))
+	case time.Time:
+		writeTagged(w, 'T', value.Unix())
+	case []interface{}:
+		writeTagged(w, 'A', uint32(len(value)))
+		for _, item := range value {
+			writeValue(w, item)
+		}
+	case amqp.Table:
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		writeTagged(w, 'F', uint32(len(names)))
+		for _, name := range names {
+			writeString(w, name)
+			writeValue(w, value[name])
+		}
+	default:
+		_, _ = w.Write([]byte{'?'})
+		writeString(w, fmt.Sprintf("%T", value))
+	}
+}
+
+//writeTagged write the tag of a type followed by the value of fixed size in big endian
+func writeTagged(w io.Writer, tag byte, value interface{}) {
+	_, _ = w.Write([]byte{tag})
+	_ = binary.Write(w, binary.BigEndian, value)
+}
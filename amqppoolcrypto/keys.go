@@ -0,0 +1,70 @@
+package amqppoolcrypto
+
+import (
+	"fmt"
+	"sync"
+)
+
+//KeyProvider provide the keys of encryption or signing by their id, the id of the current key is published in the
+//headers of the messages to find the key of the deliveries after a rotation
+type KeyProvider interface {
+	Current() (id string, key []byte, err error) //get the key to seal the messages
+	Key(id string) ([]byte, error)               //get a key to open the deliveries
+}
+
+//Keys a KeyProvider of keys kept in memory, rotated by Rotate
+type Keys struct {
+	mutex   sync.RWMutex      //guard the keys
+	current string            //the id of the current key
+	keys    map[string][]byte //the keys by id
+}
+
+var _ KeyProvider = (*Keys)(nil)
+
+//NewKeys create a new Keys with the current key
+func NewKeys(id string, key []byte) *Keys {
+	return &Keys{current: id, keys: map[string][]byte{id: key}}
+}
+
+//Rotate add a key and make it the current, the previous keys keep opening the deliveries sealed with them
+func (keys *Keys) Rotate(id string, key []byte) {
+	keys.mutex.Lock()
+	defer keys.mutex.Unlock()
+
+	keys.keys[id] = key
+	keys.current = id
+}
+
+//Retire remove a key, the deliveries sealed with it can't be opened anymore. The current key can't be retired
+func (keys *Keys) Retire(id string) error {
+	keys.mutex.Lock()
+	defer keys.mutex.Unlock()
+
+	if id == keys.current {
+		return fmt.Errorf("the key %q is the current key", id)
+	}
+
+	delete(keys.keys, id)
+	return nil
+}
+
+//Current implementing the KeyProvider interface
+func (keys *Keys) Current() (string, []byte, error) {
+	keys.mutex.RLock()
+	defer keys.mutex.RUnlock()
+
+	return keys.current, keys.keys[keys.current], nil
+}
+
+//Key implementing the KeyProvider interface
+func (keys *Keys) Key(id string) ([]byte, error) {
+	keys.mutex.RLock()
+	defer keys.mutex.RUnlock()
+
+	key, exist := keys.keys[id]
+	if !exist {
+		return nil, fmt.Errorf("the key %q was not found", id)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,34 @@
+//Package amqppoolmsgpack encode and decode the bodies of the messages in MessagePack, with the content type
+//application/x-msgpack:
+//
+//	consumer := amqppool.NewConsumer[Order](pool, "orders", amqppool.WithCodecs(amqppoolmsgpack.Codec))
+package amqppoolmsgpack
+
+import (
+	"github.com/gmarcial/amqppool"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+//ContentType the content type of the bodies encoded in MessagePack
+const ContentType = "application/x-msgpack"
+
+//Codec encode and decode the bodies in MessagePack
+var Codec amqppool.Codec = codec{}
+
+//codec implementing the amqppool.Codec interface with MessagePack
+type codec struct{}
+
+//ContentType implementing the amqppool.Codec interface
+func (codec) ContentType() string {
+	return ContentType
+}
+
+//Marshal implementing the amqppool.Codec interface
+func (codec) Marshal(value interface{}) ([]byte, error) {
+	return msgpack.Marshal(value)
+}
+
+//Unmarshal implementing the amqppool.Codec interface
+func (codec) Unmarshal(body []byte, value interface{}) error {
+	return msgpack.Unmarshal(body, value)
+}
@@ -0,0 +1,27 @@
+package amqppoolmsgpack
+
+import (
+	"testing"
+)
+
+func TestShouldEncodeAndDecodeTheMessagesInMessagePack(t *testing.T) {
+	//Arrange
+	type order struct {
+		ID    int
+		Items []string
+	}
+
+	//Action
+	body, err := Codec.Marshal(order{ID: 1, Items: []string{"book"}})
+	var decoded order
+	decodeErr := Codec.Unmarshal(body, &decoded)
+
+	//Assert
+	if err != nil || decodeErr != nil {
+		t.Fatalf("Occurred a error to encode or decode the message: %v, %v", err, decodeErr)
+	}
+
+	if decoded.ID != 1 || len(decoded.Items) != 1 || decoded.Items[0] != "book" {
+		t.Errorf("The message decoded is different of expected: %+v", decoded)
+	}
+}
@@ -0,0 +1,39 @@
+package amqppoolotel
+
+import (
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+//HeadersCarrier adapt the headers of a message to inject and extract the trace context, implementing
+//propagation.TextMapCarrier
+type HeadersCarrier amqp.Table
+
+var _ propagation.TextMapCarrier = HeadersCarrier(nil)
+
+//Get get the value of a header, only the headers of type string or bytes have value
+func (carrier HeadersCarrier) Get(key string) string {
+	switch value := carrier[key].(type) {
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	default:
+		return ""
+	}
+}
+
+//Set set the value of a header
+func (carrier HeadersCarrier) Set(key, value string) {
+	carrier[key] = value
+}
+
+//Keys get the names of the headers
+func (carrier HeadersCarrier) Keys() []string {
+	keys := make([]string, 0, len(carrier))
+	for key := range carrier {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
@@ -0,0 +1,161 @@
+//Package amqppoolotel provides the tracing of the publishes and consumes with OpenTelemetry, propagating the
+//trace context in the headers of the messages.
+//
+//The publishes create producer spans and inject the trace context in the headers, the consumes extract it from the
+//deliveries to create consumer spans linked to the producer spans:
+//
+//	tracing := amqppoolotel.NewTracing()
+//	err := tracing.Publish(ctx, reusableChannel, "orders", "order.created", false, false, publishing)
+//
+//	ctx, span := tracing.StartConsume(ctx, delivery)
+//	defer span.End()
+package amqppoolotel
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/gmarcial/amqppool/amqppoolotel"
+	defaultExchange     = "(default)"
+)
+
+//Publisher publish messages, implemented by amqppool.ReusableChannel and amqp.Channel
+type Publisher interface {
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
+//Tracing create the spans of the publishes and consumes
+type Tracing struct {
+	tracer     trace.Tracer                  //create the spans
+	propagator propagation.TextMapPropagator //inject and extract the trace context of the headers
+}
+
+//Option configure a Tracing in its creation
+type Option func(tracing *tracingConfig)
+
+//tracingConfig the configuration of a Tracing
+type tracingConfig struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+}
+
+//WithTracerProvider configure the provider of the tracer, by default the global provider
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(config *tracingConfig) {
+		config.provider = provider
+	}
+}
+
+//WithPropagator configure the propagator of the trace context, by default the W3C trace context
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(config *tracingConfig) {
+		config.propagator = propagator
+	}
+}
+
+//NewTracing create a new Tracing
+func NewTracing(options ...Option) *Tracing {
+	config := &tracingConfig{
+		provider:   otel.GetTracerProvider(),
+		propagator: propagation.TraceContext{},
+	}
+
+	for _, option := range options {
+		option(config)
+	}
+
+	return &Tracing{
+		tracer:     config.provider.Tracer(instrumentationName),
+		propagator: config.propagator,
+	}
+}
+
+//Publish publish a message in a producer span, injecting the trace context in its headers
+func (tracing *Tracing) Publish(ctx context.Context, publisher Publisher, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	attributes := append(messageAttributes(exchange, key, msg.MessageId, msg.CorrelationId, len(msg.Body)),
+		semconv.MessagingDestinationKindTopic)
+
+	ctx, span := tracing.tracer.Start(ctx, destinationName(exchange)+" send",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attributes...))
+	defer span.End()
+
+	headers := make(amqp.Table, len(msg.Headers)+1)
+	for name, value := range msg.Headers {
+		headers[name] = value
+	}
+	tracing.propagator.Inject(ctx, HeadersCarrier(headers))
+	msg.Headers = headers
+
+	err := publisher.Publish(exchange, key, mandatory, immediate, msg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
+
+//StartConsume start a consumer span of the process of a delivery, linked to the producer span of the trace
+//context in its headers, the span must be ended when the process finish
+func (tracing *Tracing) StartConsume(ctx context.Context, delivery amqp.Delivery) (context.Context, trace.Span) {
+	producer := trace.SpanContextFromContext(tracing.Extract(context.Background(), delivery))
+
+	options := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(append(
+			messageAttributes(delivery.Exchange, delivery.RoutingKey, delivery.MessageId, delivery.CorrelationId, len(delivery.Body)),
+			semconv.MessagingOperationProcess,
+			semconv.MessagingConsumerIDKey.String(delivery.ConsumerTag))...),
+	}
+	if producer.IsValid() {
+		options = append(options, trace.WithLinks(trace.Link{SpanContext: producer}))
+	}
+
+	return tracing.tracer.Start(ctx, destinationName(delivery.Exchange)+" process", options...)
+}
+
+//Extract extract the trace context of the headers of a delivery to a context
+func (tracing *Tracing) Extract(ctx context.Context, delivery amqp.Delivery) context.Context {
+	return tracing.propagator.Extract(ctx, HeadersCarrier(delivery.Headers))
+}
+
+//messageAttributes the attributes of the messaging semantic conventions of a message
+func messageAttributes(exchange, key, messageID, correlationID string, size int) []attribute.KeyValue {
+	attributes := []attribute.KeyValue{
+		semconv.MessagingSystemKey.String("rabbitmq"),
+		semconv.MessagingProtocolKey.String("AMQP"),
+		semconv.MessagingProtocolVersionKey.String("0.9.1"),
+		semconv.MessagingDestinationKey.String(destinationName(exchange)),
+		semconv.MessagingRabbitmqRoutingKeyKey.String(key),
+		semconv.MessagingMessagePayloadSizeBytesKey.Int(size),
+	}
+
+	if messageID != "" {
+		attributes = append(attributes, semconv.MessagingMessageIDKey.String(messageID))
+	}
+
+	if correlationID != "" {
+		attributes = append(attributes, semconv.MessagingConversationIDKey.String(correlationID))
+	}
+
+	return attributes
+}
+
+//destinationName the name of the exchange in the spans, the default exchange don't have name
+func destinationName(exchange string) string {
+	if exchange == "" {
+		return defaultExchange
+	}
+
+	return exchange
+}
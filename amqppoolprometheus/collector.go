@@ -0,0 +1,137 @@
+//Package amqppoolprometheus provides a prometheus.Collector of the metrics of an amqppool.Pool.
+//
+//The collector is the observer of the pool and read its stats when is collected, all metrics are labelled by
+//the name of the pool:
+//
+//	collector := amqppoolprometheus.NewCollector("orders")
+//	pool, err := amqppool.NewPool(connectionString, 10, amqppool.WithObserver(collector))
+//	collector.Watch(pool)
+//	prometheus.MustRegister(collector)
+package amqppoolprometheus
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/streadway/amqp"
+)
+
+const namespace = "amqppool"
+
+//Collector collect the metrics of a pool, implementing prometheus.Collector and amqppool.Observer
+type Collector struct {
+	mutex sync.Mutex     //guard the pool watched
+	pool  *amqppool.Pool //pool of which the stats are collected
+
+	idle       *prometheus.Desc
+	inUse      *prometheus.Desc
+	max        *prometheus.Desc
+	acquires   *prometheus.Desc
+	timeouts   *prometheus.Desc
+	reconnects *prometheus.Desc
+
+	channelErrors *prometheus.CounterVec //channel errors by reply code
+	acquireWait   prometheus.Histogram   //time waited to get a reusable channel
+	publish       prometheus.Histogram   //latency of the publishes
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+var _ amqppool.Observer = (*Collector)(nil)
+
+//NewCollector create a new collector of the metrics of a pool, labelled with its name
+func NewCollector(name string) *Collector {
+	labels := prometheus.Labels{"pool": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", metric), help, nil, labels)
+	}
+
+	return &Collector{
+		idle:       desc("channels_idle", "Quantity of reusable channels released."),
+		inUse:      desc("channels_in_use", "Quantity of reusable channels in use."),
+		max:        desc("channels_max", "Maximum quantity of channels of the pool."),
+		acquires:   desc("acquires_total", "Total of reusable channels got of the pool."),
+		timeouts:   desc("acquire_timeouts_total", "Total of gets that gave up of wait a reusable channel."),
+		reconnects: desc("reconnects_total", "Total of reconnections with the broker."),
+		channelErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "channel_errors_total",
+			Help:        "Total of channels closed by exceptions of the broker, by reply code.",
+			ConstLabels: labels,
+		}, []string{"code"}),
+		acquireWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "acquire_wait_seconds",
+			Help:        "Time waited to get a reusable channel of the pool.",
+			ConstLabels: labels,
+			Buckets:     []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1, 5},
+		}),
+		publish: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "publish_duration_seconds",
+			Help:        "Latency of the publishes by the reusable channels.",
+			ConstLabels: labels,
+			Buckets:     prometheus.DefBuckets,
+		}),
+	}
+}
+
+//Watch set the pool of which the stats are collected, the gauges and counters of the stats are only collected
+//after watch a pool
+func (collector *Collector) Watch(pool *amqppool.Pool) {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+
+	collector.pool = pool
+}
+
+//Describe implementing the prometheus.Collector interface
+func (collector *Collector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- collector.idle
+	descs <- collector.inUse
+	descs <- collector.max
+	descs <- collector.acquires
+	descs <- collector.timeouts
+	descs <- collector.reconnects
+	collector.channelErrors.Describe(descs)
+	collector.acquireWait.Describe(descs)
+	collector.publish.Describe(descs)
+}
+
+//Collect implementing the prometheus.Collector interface
+func (collector *Collector) Collect(metrics chan<- prometheus.Metric) {
+	collector.mutex.Lock()
+	pool := collector.pool
+	collector.mutex.Unlock()
+
+	if pool != nil {
+		stats := pool.Stats()
+		metrics <- prometheus.MustNewConstMetric(collector.idle, prometheus.GaugeValue, float64(stats.Idle))
+		metrics <- prometheus.MustNewConstMetric(collector.inUse, prometheus.GaugeValue, float64(stats.InUse))
+		metrics <- prometheus.MustNewConstMetric(collector.max, prometheus.GaugeValue, float64(stats.MaxChannels))
+		metrics <- prometheus.MustNewConstMetric(collector.acquires, prometheus.CounterValue, float64(stats.AcquireCount))
+		metrics <- prometheus.MustNewConstMetric(collector.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+		metrics <- prometheus.MustNewConstMetric(collector.reconnects, prometheus.CounterValue, float64(stats.Reconnects))
+	}
+
+	collector.channelErrors.Collect(metrics)
+	collector.acquireWait.Collect(metrics)
+	collector.publish.Collect(metrics)
+}
+
+//ObserveAcquire implementing the amqppool.Observer interface
+func (collector *Collector) ObserveAcquire(wait time.Duration, err error) {
+	collector.acquireWait.Observe(wait.Seconds())
+}
+
+//ObservePublish implementing the amqppool.Observer interface
+func (collector *Collector) ObservePublish(latency time.Duration, err error) {
+	collector.publish.Observe(latency.Seconds())
+}
+
+//ObserveChannelError implementing the amqppool.Observer interface
+func (collector *Collector) ObserveChannelError(err *amqp.Error) {
+	collector.channelErrors.WithLabelValues(strconv.Itoa(err.Code)).Inc()
+}
@@ -0,0 +1,62 @@
+//Package amqppoolprotobuf encode and decode the bodies of the messages in protobuf, with the content type
+//application/x-protobuf:
+//
+//	consumer := amqppool.NewConsumer[*orderspb.Order](pool, "orders", amqppool.WithCodecs(amqppoolprotobuf.Codec))
+package amqppoolprotobuf
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gmarcial/amqppool"
+	"google.golang.org/protobuf/proto"
+)
+
+//ContentType the content type of the bodies encoded in protobuf
+const ContentType = "application/x-protobuf"
+
+//Codec encode and decode the bodies in protobuf, the values must be a proto.Message
+var Codec amqppool.Codec = codec{}
+
+//codec implementing the amqppool.Codec interface with protobuf
+type codec struct{}
+
+//ContentType implementing the amqppool.Codec interface
+func (codec) ContentType() string {
+	return ContentType
+}
+
+//Marshal implementing the amqppool.Codec interface
+func (codec) Marshal(value interface{}) ([]byte, error) {
+	message, isMessage := value.(proto.Message)
+	if !isMessage {
+		return nil, fmt.Errorf("the value %T is not a proto.Message", value)
+	}
+
+	return proto.Marshal(message)
+}
+
+//Unmarshal implementing the amqppool.Codec interface, the value can be a proto.Message or a pointer to one, which is
+//allocated when nil, as the value of a amqppool.Consumer of messages
+func (codec) Unmarshal(body []byte, value interface{}) error {
+	if message, isMessage := value.(proto.Message); isMessage {
+		return proto.Unmarshal(body, message)
+	}
+
+	pointer := reflect.ValueOf(value)
+	if pointer.Kind() != reflect.Ptr || pointer.IsNil() || pointer.Elem().Kind() != reflect.Ptr {
+		return fmt.Errorf("the value %T is not a proto.Message or a pointer to one", value)
+	}
+
+	message, isMessage := reflect.New(pointer.Elem().Type().Elem()).Interface().(proto.Message)
+	if !isMessage {
+		return fmt.Errorf("the value %T is not a proto.Message or a pointer to one", value)
+	}
+
+	if err := proto.Unmarshal(body, message); err != nil {
+		return err
+	}
+
+	pointer.Elem().Set(reflect.ValueOf(message))
+	return nil
+}
@@ -0,0 +1,30 @@
+package amqppoolprotobuf
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestShouldEncodeAndDecodeTheMessagesInProtobuf(t *testing.T) {
+	//Arrange
+	message := wrapperspb.String("order created")
+
+	//Action
+	body, err := Codec.Marshal(message)
+	var decoded *wrapperspb.StringValue
+	decodeErr := Codec.Unmarshal(body, &decoded)
+
+	//Assert
+	if err != nil || decodeErr != nil {
+		t.Fatalf("Occurred a error to encode or decode the message: %v, %v", err, decodeErr)
+	}
+
+	if decoded.GetValue() != "order created" {
+		t.Errorf("The message decoded is different of expected: %v", decoded)
+	}
+
+	if err := Codec.Unmarshal(body, &struct{}{}); err == nil {
+		t.Error("The codec decoded a value which is not a proto.Message")
+	}
+}
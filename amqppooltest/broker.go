@@ -0,0 +1,542 @@
+//Package amqppooltest provides an in-memory broker amqp to test code that depends on amqppool without a RabbitMQ.
+//
+//The broker implements the exchanges direct, fanout, topic and headers, queues, bindings, publish, consume, get,
+//ack, nack, reject, dead lettering, publisher confirms, returns, transactions and direct reply-to, closing the
+//channels and connections with the same exceptions of RabbitMQ. It's plugged into a pool through its dialer:
+//
+//	broker := amqppooltest.NewBroker()
+//	pool, err := amqppool.NewPool("amqp://localhost", 10, amqppool.WithDialer(broker.Dial))
+//
+//The expiration of messages (x-message-ttl and Expiration) and the priorities are not implemented.
+package amqppooltest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/streadway/amqp"
+)
+
+//Broker represents an in-memory broker amqp with a single virtual host
+type Broker struct {
+	mutex       sync.Mutex             //guard all state of the broker, its connections and channels
+	exchanges   map[string]*exchange   //exchanges declared by name
+	queues      map[string]*queue      //queues declared by name
+	connections map[*Connection]bool   //connections open
+	sequence    uint64                 //sequence to generate the names of queues and consumers
+	faults      map[string]*amqp.Error //exceptions injected in the next call of the methods of the channels
+	latency     time.Duration          //latency added to each operation of the channels
+	refusal     error                  //error returned when dial while the connections are refused
+	unblocked   chan struct{}          //closed when the broker is unblocked, nil while not blocked
+}
+
+//exchange represents an exchange declared in the broker
+type exchange struct {
+	name       string
+	kind       string
+	durable    bool
+	autoDelete bool
+	internal   bool
+	args       amqp.Table
+	bindings   []*binding //bindings of which the exchange is the source
+	bound      bool       //indicates when the exchange had a binding, to be auto deleted
+}
+
+//binding represents a binding of an exchange to a queue or exchange
+type binding struct {
+	destination string
+	toExchange  bool
+	key         string
+	args        amqp.Table
+}
+
+//queue represents a queue declared in the broker
+type queue struct {
+	name       string
+	durable    bool
+	autoDelete bool
+	exclusive  bool
+	args       amqp.Table
+	owner      *Connection //the connection owner of an exclusive queue
+	messages   []*message  //ready messages
+	consumers  []*consumer //active consumers
+	next       int         //next consumer in the round robin
+	consumed   bool        //indicates when the queue had a consumer, to be auto deleted
+}
+
+//message represents a message published in the broker
+type message struct {
+	exchange    string
+	key         string
+	publishing  amqp.Publishing
+	redelivered bool
+}
+
+//outbox accumulate the notifications to the clients, sent after release the lock of the broker
+type outbox []func()
+
+//add append a notification
+func (out *outbox) add(notification func()) {
+	*out = append(*out, notification)
+}
+
+//flush send the notifications in order
+func (out *outbox) flush() {
+	for _, notification := range *out {
+		notification()
+	}
+}
+
+//NewBroker create a new in-memory broker with the exchanges predeclared by RabbitMQ
+func NewBroker() *Broker {
+	broker := &Broker{
+		exchanges:   make(map[string]*exchange),
+		queues:      make(map[string]*queue),
+		connections: make(map[*Connection]bool),
+		faults:      make(map[string]*amqp.Error),
+	}
+
+	broker.exchanges[""] = &exchange{name: "", kind: amqp.ExchangeDirect, durable: true}
+	for name, kind := range map[string]string{
+		"amq.direct":  amqp.ExchangeDirect,
+		"amq.fanout":  amqp.ExchangeFanout,
+		"amq.topic":   amqp.ExchangeTopic,
+		"amq.headers": amqp.ExchangeHeaders,
+		"amq.match":   amqp.ExchangeHeaders,
+	} {
+		broker.exchanges[name] = &exchange{name: name, kind: kind, durable: true}
+	}
+
+	return broker
+}
+
+//Dial open a new connection with the broker, validating the connection string, usable how amqppool.Dialer
+func (broker *Broker) Dial(connectionString string) (amqppool.Connection, error) {
+	if _, err := amqp.ParseURI(connectionString); err != nil {
+		return nil, err
+	}
+
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	if broker.refusal != nil {
+		return nil, broker.refusal
+	}
+
+	connection := &Connection{
+		broker:   broker,
+		channels: make(map[uint16]*Channel),
+		done:     make(chan struct{}),
+	}
+	broker.connections[connection] = true
+
+	return connection, nil
+}
+
+//HasExchange indicates if an exchange is declared
+func (broker *Broker) HasExchange(name string) bool {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	_, exist := broker.exchanges[name]
+	return exist
+}
+
+//HasQueue indicates if a queue is declared
+func (broker *Broker) HasQueue(name string) bool {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	_, exist := broker.queues[name]
+	return exist
+}
+
+//MessageCount get the quantity of ready messages of a queue
+func (broker *Broker) MessageCount(queue string) int {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	if declared, exist := broker.queues[queue]; exist {
+		return len(declared.messages)
+	}
+
+	return 0
+}
+
+//ConnectionCount get the quantity of connections open
+func (broker *Broker) ConnectionCount() int {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	return len(broker.connections)
+}
+
+//nextName generate a name unique in the broker
+func (broker *Broker) nextName(prefix string) string {
+	broker.sequence++
+	return fmt.Sprintf("%v-%v", prefix, broker.sequence)
+}
+
+//exception create an exception of the broker with the reply text of RabbitMQ
+func exception(code int, format string, args ...interface{}) *amqp.Error {
+	return &amqp.Error{
+		Code:    code,
+		Reason:  fmt.Sprintf("%v - %v", replyText[code], fmt.Sprintf(format, args...)),
+		Server:  true,
+		Recover: !isConnectionException(code),
+	}
+}
+
+//isConnectionException indicates if an exception close the whole connection instead of only the channel
+func isConnectionException(code int) bool {
+	return code == amqp.ConnectionForced || code == amqp.InvalidPath || code >= amqp.FrameError
+}
+
+//replyText the names of the reply codes
+var replyText = map[int]string{
+	amqp.ContentTooLarge:    "CONTENT_TOO_LARGE",
+	amqp.NoRoute:            "NO_ROUTE",
+	amqp.NoConsumers:        "NO_CONSUMERS",
+	amqp.ConnectionForced:   "CONNECTION_FORCED",
+	amqp.InvalidPath:        "INVALID_PATH",
+	amqp.AccessRefused:      "ACCESS_REFUSED",
+	amqp.NotFound:           "NOT_FOUND",
+	amqp.ResourceLocked:     "RESOURCE_LOCKED",
+	amqp.PreconditionFailed: "PRECONDITION_FAILED",
+	amqp.FrameError:         "FRAME_ERROR",
+	amqp.SyntaxError:        "SYNTAX_ERROR",
+	amqp.CommandInvalid:     "COMMAND_INVALID",
+	amqp.ChannelError:       "CHANNEL_ERROR",
+	amqp.UnexpectedFrame:    "UNEXPECTED_FRAME",
+	amqp.ResourceError:      "RESOURCE_ERROR",
+	amqp.NotAllowed:         "NOT_ALLOWED",
+	amqp.NotImplemented:     "NOT_IMPLEMENTED",
+	amqp.InternalError:      "INTERNAL_ERROR",
+}
+
+//findExchange get a declared exchange or a NOT_FOUND exception
+func (broker *Broker) findExchange(name string) (*exchange, error) {
+	declared, exist := broker.exchanges[name]
+	if !exist {
+		return nil, exception(amqp.NotFound, "no exchange '%v' in vhost '/'", name)
+	}
+
+	return declared, nil
+}
+
+//findQueue get a declared queue accessible by the connection or a NOT_FOUND or RESOURCE_LOCKED exception
+func (broker *Broker) findQueue(name string, connection *Connection) (*queue, error) {
+	declared, exist := broker.queues[name]
+	if !exist {
+		return nil, exception(amqp.NotFound, "no queue '%v' in vhost '/'", name)
+	}
+
+	if declared.exclusive && declared.owner != connection {
+		return nil, exception(amqp.ResourceLocked, "cannot obtain exclusive access to locked queue '%v' in vhost '/'", name)
+	}
+
+	return declared, nil
+}
+
+//route find the queues of destination of a message, following the bindings between exchanges
+func (broker *Broker) route(source *exchange, key string, headers amqp.Table, visited map[*exchange]bool, queues map[*queue]bool) {
+	if visited[source] {
+		return
+	}
+	visited[source] = true
+
+	if source.name == "" {
+		if declared, exist := broker.queues[key]; exist {
+			queues[declared] = true
+		}
+		return
+	}
+
+	for _, binding := range source.bindings {
+		if !matchBinding(source.kind, binding, key, headers) {
+			continue
+		}
+
+		if !binding.toExchange {
+			if destination, exist := broker.queues[binding.destination]; exist {
+				queues[destination] = true
+			}
+		} else if destination, exist := broker.exchanges[binding.destination]; exist {
+			broker.route(destination, key, headers, visited, queues)
+		}
+	}
+}
+
+//matchBinding verify if a binding match the routing key or headers of a message according to the kind of exchange
+func matchBinding(kind string, binding *binding, key string, headers amqp.Table) bool {
+	switch kind {
+	case amqp.ExchangeFanout:
+		return true
+	case amqp.ExchangeTopic:
+		return matchTopic(strings.Split(binding.key, "."), strings.Split(key, "."))
+	case amqp.ExchangeHeaders:
+		return matchHeaders(binding.args, headers)
+	default:
+		return binding.key == key
+	}
+}
+
+//matchTopic verify if the words of a routing key match a pattern, where * match one word and # zero or more
+func matchTopic(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(words); i++ {
+			if matchTopic(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(words) > 0 && matchTopic(pattern[1:], words[1:])
+	default:
+		return len(words) > 0 && pattern[0] == words[0] && matchTopic(pattern[1:], words[1:])
+	}
+}
+
+//matchHeaders verify if the headers of a message match the arguments of a binding with x-match all or any
+func matchHeaders(args, headers amqp.Table) bool {
+	mode, _ := args["x-match"].(string)
+	if mode == "" {
+		mode = "all"
+	}
+	withX := strings.HasSuffix(mode, "-with-x")
+
+	matched, compared := 0, 0
+	for key, value := range args {
+		if key == "x-match" || (strings.HasPrefix(key, "x-") && !withX) {
+			continue
+		}
+
+		compared++
+		header, exist := headers[key]
+		if exist && (value == nil || equalValues(value, header)) {
+			matched++
+		}
+	}
+
+	if strings.HasPrefix(mode, "any") {
+		return matched > 0
+	}
+
+	return matched == compared
+}
+
+//equalValues compare two values of amqp tables, considering equal the integers of distinct types
+func equalValues(a, b interface{}) bool {
+	integerA, okA := toInt64(a)
+	integerB, okB := toInt64(b)
+	if okA || okB {
+		return okA && okB && integerA == integerB
+	}
+
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+//equalTables compare two amqp tables, considering the absence equal to the default value given
+func equalTables(a, b amqp.Table, defaults amqp.Table) (string, bool) {
+	keys := make(map[string]bool)
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		valueA, existA := a[key]
+		valueB, existB := b[key]
+		if !existA {
+			valueA, existA = defaults[key]
+		}
+		if !existB {
+			valueB, existB = defaults[key]
+		}
+
+		if existA != existB || (existA && !equalValues(valueA, valueB)) {
+			return key, false
+		}
+	}
+
+	return "", true
+}
+
+//toInt64 convert the integer types accepted by an amqp table
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case byte:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+//enqueue add a message to a queue applying the maximum length, returning false when the message was rejected
+func (broker *Broker) enqueue(destination *queue, msg *message, out *outbox) bool {
+	maxLength, hasMaxLength := toInt64(destination.args["x-max-length"])
+	maxLengthBytes, hasMaxLengthBytes := toInt64(destination.args["x-max-length-bytes"])
+	overflow, _ := destination.args["x-overflow"].(string)
+
+	exceeded := func(extra *message) bool {
+		length, bytes := int64(len(destination.messages)), int64(0)
+		for _, ready := range destination.messages {
+			bytes += int64(len(ready.publishing.Body))
+		}
+		if extra != nil {
+			length, bytes = length+1, bytes+int64(len(extra.publishing.Body))
+		}
+
+		return (hasMaxLength && length > maxLength) || (hasMaxLengthBytes && bytes > maxLengthBytes)
+	}
+
+	if overflow == string(amqppool.OverflowRejectPublish) || overflow == string(amqppool.OverflowRejectPublishDLX) {
+		if exceeded(msg) {
+			if overflow == string(amqppool.OverflowRejectPublishDLX) {
+				broker.deadLetter(destination, msg, "maxlen", out)
+			}
+			return false
+		}
+	}
+
+	destination.messages = append(destination.messages, msg)
+	for exceeded(nil) && len(destination.messages) > 0 {
+		dropped := destination.messages[0]
+		destination.messages = destination.messages[1:]
+		broker.deadLetter(destination, dropped, "maxlen", out)
+	}
+
+	broker.dispatch(destination, out)
+
+	return true
+}
+
+//deadLetter republish a message to the dead letter exchange of the queue, discarding it when there is none
+func (broker *Broker) deadLetter(source *queue, msg *message, reason string, out *outbox) {
+	exchangeName, ok := source.args["x-dead-letter-exchange"].(string)
+	if !ok {
+		return
+	}
+
+	deadLetterExchange, exist := broker.exchanges[exchangeName]
+	if !exist {
+		return
+	}
+
+	key := msg.key
+	if deadLetterKey, ok := source.args["x-dead-letter-routing-key"].(string); ok {
+		key = deadLetterKey
+	}
+
+	publishing := msg.publishing
+	publishing.Headers = copyTable(publishing.Headers)
+	if _, exist := publishing.Headers["x-first-death-reason"]; !exist {
+		publishing.Headers["x-first-death-reason"] = reason
+		publishing.Headers["x-first-death-queue"] = source.name
+		publishing.Headers["x-first-death-exchange"] = msg.exchange
+	}
+
+	queues := make(map[*queue]bool)
+	broker.route(deadLetterExchange, key, publishing.Headers, make(map[*exchange]bool), queues)
+	for destination := range queues {
+		broker.enqueue(destination, &message{exchange: exchangeName, key: key, publishing: publishing}, out)
+	}
+}
+
+//dispatch deliver the ready messages of a queue to its consumers with round robin, respecting the prefetch
+func (broker *Broker) dispatch(source *queue, out *outbox) {
+	for len(source.messages) > 0 {
+		candidates := source.consumers
+		if singleActive, _ := source.args["x-single-active-consumer"].(bool); singleActive && len(candidates) > 1 {
+			candidates = candidates[:1]
+		}
+
+		var selected *consumer
+		for i := 0; i < len(candidates); i++ {
+			candidate := candidates[(source.next+i)%len(candidates)]
+			if candidate.hasCapacity() {
+				selected = candidate
+				source.next = (source.next + i + 1) % len(candidates)
+				break
+			}
+		}
+
+		if selected == nil {
+			return
+		}
+
+		msg := source.messages[0]
+		source.messages = source.messages[1:]
+		selected.deliver(source, msg)
+	}
+}
+
+//deleteQueue remove a queue with its bindings, cancelling its consumers
+func (broker *Broker) deleteQueue(deleted *queue, out *outbox) {
+	delete(broker.queues, deleted.name)
+
+	for _, source := range broker.exchanges {
+		broker.removeBindings(source, func(binding *binding) bool {
+			return !binding.toExchange && binding.destination == deleted.name
+		})
+	}
+
+	for _, cancelled := range deleted.consumers {
+		cancelled.channel.removeConsumer(cancelled, true, out)
+	}
+	deleted.consumers = nil
+}
+
+//deleteExchange remove an exchange with the bindings of which is the destination
+func (broker *Broker) deleteExchange(deleted *exchange) {
+	delete(broker.exchanges, deleted.name)
+
+	for _, source := range broker.exchanges {
+		broker.removeBindings(source, func(binding *binding) bool {
+			return binding.toExchange && binding.destination == deleted.name
+		})
+	}
+}
+
+//removeBindings remove the bindings of an exchange that match, deleting the exchange auto delete without bindings
+func (broker *Broker) removeBindings(source *exchange, match func(binding *binding) bool) {
+	bindings := source.bindings[:0]
+	for _, binding := range source.bindings {
+		if !match(binding) {
+			bindings = append(bindings, binding)
+		}
+	}
+	source.bindings = bindings
+
+	if source.autoDelete && source.bound && len(source.bindings) == 0 {
+		broker.deleteExchange(source)
+	}
+}
+
+//copyTable copy a table to not share it between messages
+func copyTable(table amqp.Table) amqp.Table {
+	copied := make(amqp.Table, len(table))
+	for key, value := range table {
+		copied[key] = value
+	}
+
+	return copied
+}
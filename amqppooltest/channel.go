@@ -0,0 +1,1162 @@
+package amqppooltest
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/streadway/amqp"
+)
+
+//Channel represents a channel with the in-memory broker, implementing amqppool.Channel and amqp.Acknowledger
+type Channel struct {
+	broker        *Broker                   //the broker of the channel
+	connection    *Connection               //the connection of the channel
+	id            uint16                    //number of the channel in the connection
+	closed        bool                      //indicates when the channel was closed
+	deliveryTag   uint64                    //last delivery tag of the channel
+	unacked       map[uint64]*unacked       //deliveries waiting acknowledgement by tag
+	consumers     map[string]*consumer      //consumers by tag
+	prefetchCount int                       //maximum of unacked deliveries by consumer
+	globalCount   int                       //maximum of unacked deliveries of the channel
+	confirming    bool                      //indicates when the channel is in confirm mode
+	published     uint64                    //last sequence of publishing in confirm mode
+	transactional bool                      //indicates when the channel is in transaction mode
+	transaction   []func(out *outbox) error //operations waiting the commit of the transaction
+	notifyMutex   sync.Mutex                //guard the notifications to the listeners
+	notifyClosed  bool                      //indicates when the listeners were closed
+	closes        []chan *amqp.Error        //listeners of when the channel is closed
+	cancels       []chan string             //listeners of consumers cancelled by the broker
+	flows         []chan bool               //listeners of flow control
+	returns       []chan amqp.Return        //listeners of unroutable mandatory messages
+	publishes     []chan amqp.Confirmation  //listeners of publisher confirms
+	replyQueue    *queue                    //the pseudo-queue of the consumer of direct reply-to, if any
+}
+
+//unacked represents a delivery waiting acknowledgement
+type unacked struct {
+	tag      uint64
+	message  *message
+	queue    *queue
+	consumer *consumer //the consumer of the delivery, nil when was by get
+}
+
+//consumer represents a consumer of a queue, forwarding its deliveries to the go channel returned by Consume
+type consumer struct {
+	tag        string
+	channel    *Channel
+	queue      *queue
+	autoAck    bool
+	exclusive  bool
+	unacked    int                //quantity of deliveries waiting acknowledgement
+	pending    []amqp.Delivery    //deliveries not yet forwarded
+	signal     chan struct{}      //signal new pending deliveries
+	done       chan struct{}      //closed when the consumer is cancelled
+	deliveries chan amqp.Delivery //go channel of the deliveries
+}
+
+var _ amqppool.Channel = (*Channel)(nil)
+var _ amqppool.NumberedChannel = (*Channel)(nil)
+var _ amqp.Acknowledger = (*Channel)(nil)
+
+//Number get the number of the channel in the connection
+func (channel *Channel) Number() uint16 {
+	return channel.id
+}
+
+//call run an operation of a method with the lock of the broker, closing the channel or the connection on exceptions
+func (channel *Channel) call(method string, operation func(out *outbox) error) error {
+	broker := channel.broker
+	out := &outbox{}
+
+	broker.delay()
+
+	broker.mutex.Lock()
+	err := channel.run(func(out *outbox) error {
+		if injected := broker.takeFault(method); injected != nil {
+			return injected
+		}
+
+		return operation(out)
+	}, out)
+	broker.mutex.Unlock()
+
+	out.flush()
+
+	return err
+}
+
+//run run an operation in the open channel, the lock of the broker must be held
+func (channel *Channel) run(operation func(out *outbox) error, out *outbox) error {
+	if channel.closed {
+		return amqp.ErrClosed
+	}
+
+	err := operation(out)
+
+	var amqpErr *amqp.Error
+	if errors.As(err, &amqpErr) && amqpErr.Server {
+		if isConnectionException(amqpErr.Code) {
+			channel.connection.shutdown(amqpErr, out)
+		} else {
+			channel.shutdown(amqpErr, out)
+		}
+	}
+
+	return err
+}
+
+//transact run the operation or defer it to the commit when the channel is transactional
+func (channel *Channel) transact(operation func(out *outbox) error, out *outbox) error {
+	if channel.transactional {
+		channel.transaction = append(channel.transaction, operation)
+		return nil
+	}
+
+	return operation(out)
+}
+
+//shutdown close the channel, cancelling the consumers, requeuing the unacked deliveries and notifying the listeners
+func (channel *Channel) shutdown(err *amqp.Error, out *outbox) {
+	if channel.closed {
+		return
+	}
+	channel.closed = true
+	delete(channel.connection.channels, channel.id)
+
+	for _, cancelled := range channel.consumers {
+		channel.removeConsumer(cancelled, false, out)
+	}
+
+	channel.requeue(channel.unackedTags(0, true), out)
+
+	out.add(func() {
+		channel.notifyMutex.Lock()
+		defer channel.notifyMutex.Unlock()
+
+		channel.notifyClosed = true
+		for _, notifier := range channel.closes {
+			if err != nil {
+				notifier <- err
+			}
+			close(notifier)
+		}
+		for _, notifier := range channel.cancels {
+			close(notifier)
+		}
+		for _, notifier := range channel.flows {
+			close(notifier)
+		}
+		for _, notifier := range channel.returns {
+			close(notifier)
+		}
+		for _, notifier := range channel.publishes {
+			close(notifier)
+		}
+		channel.closes, channel.cancels, channel.flows, channel.returns, channel.publishes = nil, nil, nil, nil, nil
+	})
+}
+
+//notify send a notification to the listeners while they are open
+func (channel *Channel) notify(send func()) func() {
+	return func() {
+		channel.notifyMutex.Lock()
+		defer channel.notifyMutex.Unlock()
+
+		if !channel.notifyClosed {
+			send()
+		}
+	}
+}
+
+//Close close the channel
+func (channel *Channel) Close() error {
+	return channel.call("Close", func(out *outbox) error {
+		channel.shutdown(nil, out)
+		return nil
+	})
+}
+
+//NotifyClose register a listener for when the channel is closed, receiving the exception when was not by Close
+func (channel *Channel) NotifyClose(c chan *amqp.Error) chan *amqp.Error {
+	channel.register(func() { channel.closes = append(channel.closes, c) }, func() { close(c) })
+	return c
+}
+
+//NotifyCancel register a listener of the consumers cancelled by the broker, like when its queue is deleted
+func (channel *Channel) NotifyCancel(c chan string) chan string {
+	channel.register(func() { channel.cancels = append(channel.cancels, c) }, func() { close(c) })
+	return c
+}
+
+//NotifyFlow register a listener of flow control
+func (channel *Channel) NotifyFlow(c chan bool) chan bool {
+	channel.register(func() { channel.flows = append(channel.flows, c) }, func() { close(c) })
+	return c
+}
+
+//NotifyReturn register a listener of mandatory messages that couldn't be routed
+func (channel *Channel) NotifyReturn(c chan amqp.Return) chan amqp.Return {
+	channel.register(func() { channel.returns = append(channel.returns, c) }, func() { close(c) })
+	return c
+}
+
+//NotifyPublish register a listener of publisher confirms
+func (channel *Channel) NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation {
+	channel.register(func() { channel.publishes = append(channel.publishes, confirm) }, func() { close(confirm) })
+	return confirm
+}
+
+//NotifyConfirm register listeners of acks and nacks of publisher confirms
+func (channel *Channel) NotifyConfirm(ack, nack chan uint64) (chan uint64, chan uint64) {
+	confirms := channel.NotifyPublish(make(chan amqp.Confirmation, len(ack)+len(nack)))
+
+	go func() {
+		for confirmation := range confirms {
+			if confirmation.Ack {
+				ack <- confirmation.DeliveryTag
+			} else {
+				nack <- confirmation.DeliveryTag
+			}
+		}
+
+		close(ack)
+		if nack != ack {
+			close(nack)
+		}
+	}()
+
+	return ack, nack
+}
+
+//register add a listener, closing it immediately when the channel is already closed
+func (channel *Channel) register(add func(), closeListener func()) {
+	channel.notifyMutex.Lock()
+	defer channel.notifyMutex.Unlock()
+
+	if channel.notifyClosed {
+		closeListener()
+		return
+	}
+
+	add()
+}
+
+//Qos limit the quantity of unacked deliveries by consumer or, when global, by channel
+func (channel *Channel) Qos(prefetchCount, prefetchSize int, global bool) error {
+	return channel.call("Qos", func(out *outbox) error {
+		if prefetchSize != 0 {
+			return exception(amqp.NotImplemented, "prefetch_size!=0 (%v)", prefetchSize)
+		}
+
+		if global {
+			channel.globalCount = prefetchCount
+		} else {
+			channel.prefetchCount = prefetchCount
+		}
+
+		return nil
+	})
+}
+
+//Flow only accept active flows, like RabbitMQ
+func (channel *Channel) Flow(active bool) error {
+	return channel.call("Flow", func(out *outbox) error {
+		if !active {
+			return exception(amqp.NotImplemented, "active=false")
+		}
+
+		return nil
+	})
+}
+
+//Confirm put the channel in confirm mode
+func (channel *Channel) Confirm(noWait bool) error {
+	return channel.call("Confirm", func(out *outbox) error {
+		if channel.transactional {
+			return exception(amqp.PreconditionFailed, "cannot switch from tx to confirm mode")
+		}
+
+		channel.confirming = true
+		return nil
+	})
+}
+
+//Tx put the channel in transaction mode
+func (channel *Channel) Tx() error {
+	return channel.call("Tx", func(out *outbox) error {
+		if channel.confirming {
+			return exception(amqp.PreconditionFailed, "cannot switch from confirm to tx mode")
+		}
+
+		channel.transactional = true
+		return nil
+	})
+}
+
+//TxCommit run the publishings and acknowledgements of the transaction
+func (channel *Channel) TxCommit() error {
+	return channel.call("TxCommit", func(out *outbox) error {
+		if !channel.transactional {
+			return exception(amqp.PreconditionFailed, "channel is not transactional")
+		}
+
+		operations := channel.transaction
+		channel.transaction = nil
+		for _, operation := range operations {
+			if err := operation(out); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+//TxRollback discard the publishings and acknowledgements of the transaction
+func (channel *Channel) TxRollback() error {
+	return channel.call("TxRollback", func(out *outbox) error {
+		if !channel.transactional {
+			return exception(amqp.PreconditionFailed, "channel is not transactional")
+		}
+
+		channel.transaction = nil
+		return nil
+	})
+}
+
+//ExchangeDeclare declare an exchange or verify the equivalence of an existing one
+func (channel *Channel) ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	if err := args.Validate(); err != nil {
+		return err
+	}
+
+	return channel.call("ExchangeDeclare", func(out *outbox) error {
+		broker := channel.broker
+
+		if name == "" {
+			return exception(amqp.AccessRefused, "operation not permitted on the default exchange")
+		}
+
+		if declared, exist := broker.exchanges[name]; exist {
+			return equivalentExchange(declared, kind, durable, autoDelete, internal, args)
+		}
+
+		if strings.HasPrefix(name, "amq.") {
+			return exception(amqp.AccessRefused, "exchange name '%v' contains reserved prefix 'amq.*'", name)
+		}
+
+		switch kind {
+		case amqp.ExchangeDirect, amqp.ExchangeFanout, amqp.ExchangeTopic, amqp.ExchangeHeaders:
+		default:
+			return exception(amqp.CommandInvalid, "unknown exchange type '%v'", kind)
+		}
+
+		broker.exchanges[name] = &exchange{
+			name:       name,
+			kind:       kind,
+			durable:    durable,
+			autoDelete: autoDelete,
+			internal:   internal,
+			args:       copyTable(args),
+		}
+
+		return nil
+	})
+}
+
+//equivalentExchange verify if the declaration is equivalent to the exchange declared
+func equivalentExchange(declared *exchange, kind string, durable, autoDelete, internal bool, args amqp.Table) error {
+	inequivalent := func(property string, received, current interface{}) error {
+		return exception(amqp.PreconditionFailed, "inequivalent arg '%v' for exchange '%v' in vhost '/': received '%v' but current is '%v'",
+			property, declared.name, received, current)
+	}
+
+	switch {
+	case declared.kind != kind:
+		return inequivalent("type", kind, declared.kind)
+	case declared.durable != durable:
+		return inequivalent("durable", durable, declared.durable)
+	case declared.autoDelete != autoDelete:
+		return inequivalent("auto_delete", autoDelete, declared.autoDelete)
+	case declared.internal != internal:
+		return inequivalent("internal", internal, declared.internal)
+	}
+
+	if key, equal := equalTables(args, declared.args, nil); !equal {
+		return inequivalent(key, args[key], declared.args[key])
+	}
+
+	return nil
+}
+
+//ExchangeDeclarePassive verify if an exchange exist
+func (channel *Channel) ExchangeDeclarePassive(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	return channel.call("ExchangeDeclarePassive", func(out *outbox) error {
+		_, err := channel.broker.findExchange(name)
+		return err
+	})
+}
+
+//ExchangeDelete delete an exchange, which don't exist is ignored
+func (channel *Channel) ExchangeDelete(name string, ifUnused, noWait bool) error {
+	return channel.call("ExchangeDelete", func(out *outbox) error {
+		broker := channel.broker
+
+		if name == "" || strings.HasPrefix(name, "amq.") {
+			return exception(amqp.AccessRefused, "operation not permitted on the exchange '%v'", name)
+		}
+
+		declared, exist := broker.exchanges[name]
+		if !exist {
+			return nil
+		}
+
+		if ifUnused && len(declared.bindings) > 0 {
+			return exception(amqp.PreconditionFailed, "exchange '%v' in vhost '/' in use", name)
+		}
+
+		broker.deleteExchange(declared)
+
+		return nil
+	})
+}
+
+//ExchangeBind bind an exchange destination to an exchange source
+func (channel *Channel) ExchangeBind(destination, key, source string, noWait bool, args amqp.Table) error {
+	if err := args.Validate(); err != nil {
+		return err
+	}
+
+	return channel.call("ExchangeBind", func(out *outbox) error {
+		sourceExchange, destinationExchange, err := channel.findExchanges(source, destination)
+		if err != nil {
+			return err
+		}
+
+		addBinding(sourceExchange, &binding{destination: destinationExchange.name, toExchange: true, key: key, args: copyTable(args)})
+
+		return nil
+	})
+}
+
+//ExchangeUnbind remove a binding between exchanges
+func (channel *Channel) ExchangeUnbind(destination, key, source string, noWait bool, args amqp.Table) error {
+	return channel.call("ExchangeUnbind", func(out *outbox) error {
+		sourceExchange, _, err := channel.findExchanges(source, destination)
+		if err != nil {
+			return err
+		}
+
+		channel.broker.removeBindings(sourceExchange, func(binding *binding) bool {
+			return binding.toExchange && sameBinding(binding, destination, key, args)
+		})
+
+		return nil
+	})
+}
+
+//findExchanges find the exchanges of a binding between exchanges
+func (channel *Channel) findExchanges(source, destination string) (*exchange, *exchange, error) {
+	if source == "" || destination == "" {
+		return nil, nil, exception(amqp.AccessRefused, "operation not permitted on the default exchange")
+	}
+
+	sourceExchange, err := channel.broker.findExchange(source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	destinationExchange, err := channel.broker.findExchange(destination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sourceExchange, destinationExchange, nil
+}
+
+//addBinding add a binding to the source when is not a duplicate
+func addBinding(source *exchange, added *binding) {
+	for _, existing := range source.bindings {
+		if existing.toExchange == added.toExchange && sameBinding(existing, added.destination, added.key, added.args) {
+			return
+		}
+	}
+
+	source.bindings = append(source.bindings, added)
+	source.bound = true
+}
+
+//sameBinding verify if a binding have the destination, key and arguments given
+func sameBinding(existing *binding, destination, key string, args amqp.Table) bool {
+	_, equal := equalTables(existing.args, args, nil)
+	return existing.destination == destination && existing.key == key && equal
+}
+
+//QueueDeclare declare a queue or verify the equivalence of an existing one, an empty name generate a name
+func (channel *Channel) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	if err := args.Validate(); err != nil {
+		return amqp.Queue{}, err
+	}
+
+	var declared *queue
+	err := channel.call("QueueDeclare", func(out *outbox) error {
+		broker := channel.broker
+
+		if name == "" {
+			name = broker.nextName("amq.gen")
+		} else if existing, exist := broker.queues[name]; exist {
+			if existing.exclusive && existing.owner != channel.connection {
+				return exception(amqp.ResourceLocked, "cannot obtain exclusive access to locked queue '%v' in vhost '/'", name)
+			}
+
+			declared = existing
+			return equivalentQueue(existing, durable, autoDelete, exclusive, args)
+		} else if strings.HasPrefix(name, "amq.") {
+			return exception(amqp.AccessRefused, "queue name '%v' contains reserved prefix 'amq.*'", name)
+		}
+
+		if err := amqppool.ValidateQueueArguments(args, durable, autoDelete, exclusive); err != nil {
+			return exception(amqp.PreconditionFailed, "invalid arguments for queue '%v' in vhost '/': %v", name, err)
+		}
+
+		declared = &queue{
+			name:       name,
+			durable:    durable,
+			autoDelete: autoDelete,
+			exclusive:  exclusive,
+			args:       copyTable(args),
+		}
+		if exclusive {
+			declared.owner = channel.connection
+		}
+		broker.queues[name] = declared
+
+		return nil
+	})
+	if err != nil {
+		return amqp.Queue{}, err
+	}
+
+	return inspect(channel.broker, declared), nil
+}
+
+//equivalentQueue verify if the declaration is equivalent to the queue declared
+func equivalentQueue(declared *queue, durable, autoDelete, exclusive bool, args amqp.Table) error {
+	inequivalent := func(property string, received, current interface{}) error {
+		return exception(amqp.PreconditionFailed, "inequivalent arg '%v' for queue '%v' in vhost '/': received '%v' but current is '%v'",
+			property, declared.name, received, current)
+	}
+
+	switch {
+	case declared.durable != durable:
+		return inequivalent("durable", durable, declared.durable)
+	case declared.autoDelete != autoDelete:
+		return inequivalent("auto_delete", autoDelete, declared.autoDelete)
+	case declared.exclusive != exclusive:
+		return inequivalent("exclusive", exclusive, declared.exclusive)
+	}
+
+	defaults := amqp.Table{"x-queue-type": string(amqppool.QueueTypeClassic)}
+	if key, equal := equalTables(args, declared.args, defaults); !equal {
+		return inequivalent(key, args[key], declared.args[key])
+	}
+
+	return nil
+}
+
+//inspect get the state of a queue
+func inspect(broker *Broker, declared *queue) amqp.Queue {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	return amqp.Queue{Name: declared.name, Messages: len(declared.messages), Consumers: len(declared.consumers)}
+}
+
+//QueueDeclarePassive verify if a queue exist and get its state
+func (channel *Channel) QueueDeclarePassive(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	return channel.QueueInspect(name)
+}
+
+//QueueInspect verify if a queue exist and get its state
+func (channel *Channel) QueueInspect(name string) (amqp.Queue, error) {
+	var declared *queue
+	err := channel.call("QueueInspect", func(out *outbox) error {
+		var err error
+		declared, err = channel.broker.findQueue(name, channel.connection)
+		return err
+	})
+	if err != nil {
+		return amqp.Queue{}, err
+	}
+
+	return inspect(channel.broker, declared), nil
+}
+
+//QueueBind bind a queue to an exchange
+func (channel *Channel) QueueBind(name, key, exchangeName string, noWait bool, args amqp.Table) error {
+	if err := args.Validate(); err != nil {
+		return err
+	}
+
+	return channel.call("QueueBind", func(out *outbox) error {
+		source, err := channel.findQueueBinding(name, exchangeName)
+		if err != nil {
+			return err
+		}
+
+		addBinding(source, &binding{destination: name, key: key, args: copyTable(args)})
+
+		return nil
+	})
+}
+
+//QueueUnbind remove a binding of a queue
+func (channel *Channel) QueueUnbind(name, key, exchangeName string, args amqp.Table) error {
+	return channel.call("QueueUnbind", func(out *outbox) error {
+		source, err := channel.findQueueBinding(name, exchangeName)
+		if err != nil {
+			return err
+		}
+
+		channel.broker.removeBindings(source, func(binding *binding) bool {
+			return !binding.toExchange && sameBinding(binding, name, key, args)
+		})
+
+		return nil
+	})
+}
+
+//findQueueBinding find the queue and the exchange of a binding
+func (channel *Channel) findQueueBinding(name, exchangeName string) (*exchange, error) {
+	if _, err := channel.broker.findQueue(name, channel.connection); err != nil {
+		return nil, err
+	}
+
+	if exchangeName == "" {
+		return nil, exception(amqp.AccessRefused, "operation not permitted on the default exchange")
+	}
+
+	return channel.broker.findExchange(exchangeName)
+}
+
+//QueueDelete delete a queue returning the quantity of messages deleted, which don't exist is ignored
+func (channel *Channel) QueueDelete(name string, ifUnused, ifEmpty, noWait bool) (int, error) {
+	count := 0
+	err := channel.call("QueueDelete", func(out *outbox) error {
+		broker := channel.broker
+
+		if _, exist := broker.queues[name]; !exist {
+			return nil
+		}
+
+		declared, err := broker.findQueue(name, channel.connection)
+		if err != nil {
+			return err
+		}
+
+		if ifUnused && len(declared.consumers) > 0 {
+			return exception(amqp.PreconditionFailed, "queue '%v' in vhost '/' in use", name)
+		}
+
+		if ifEmpty && len(declared.messages) > 0 {
+			return exception(amqp.PreconditionFailed, "queue '%v' in vhost '/' not empty", name)
+		}
+
+		count = len(declared.messages)
+		broker.deleteQueue(declared, out)
+
+		return nil
+	})
+
+	return count, err
+}
+
+//Publish route a message to the queues, the exceptions close the channel asynchronously like in a real broker,
+//so they are only notified to the listeners of NotifyClose
+func (channel *Channel) Publish(exchangeName, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	if err := msg.Headers.Validate(); err != nil {
+		return err
+	}
+
+	msg.Body = append([]byte(nil), msg.Body...)
+	msg.Headers = copyTable(msg.Headers)
+
+	if err := channel.broker.waitUnblocked(channel.connection); err != nil {
+		return err
+	}
+
+	err := channel.call("Publish", func(out *outbox) error {
+		return channel.transact(func(out *outbox) error {
+			return channel.publish(exchangeName, key, mandatory, immediate, msg, out)
+		}, out)
+	})
+
+	var amqpErr *amqp.Error
+	if errors.As(err, &amqpErr) && amqpErr.Server {
+		return nil
+	}
+
+	return err
+}
+
+//publish route a message, notifying the returns and confirms
+func (channel *Channel) publish(exchangeName, key string, mandatory, immediate bool, msg amqp.Publishing, out *outbox) error {
+	broker := channel.broker
+
+	if immediate {
+		return exception(amqp.NotImplemented, "immediate=true")
+	}
+
+	if msg.ReplyTo == directReplyTo {
+		if channel.replyQueue == nil {
+			return exception(amqp.PreconditionFailed, "fast reply consumer does not exist")
+		}
+		msg.ReplyTo = channel.replyQueue.name
+	}
+
+	source, err := broker.findExchange(exchangeName)
+	if err != nil {
+		return err
+	}
+
+	if source.internal {
+		return exception(amqp.AccessRefused, "cannot publish to internal exchange '%v' in vhost '/'", exchangeName)
+	}
+
+	queues := make(map[*queue]bool)
+	broker.route(source, key, msg.Headers, make(map[*exchange]bool), queues)
+
+	accepted := true
+	for destination := range queues {
+		if !broker.enqueue(destination, &message{exchange: exchangeName, key: key, publishing: msg}, out) {
+			accepted = false
+		}
+	}
+
+	if len(queues) == 0 && mandatory {
+		returned := newReturn(exchangeName, key, msg)
+		out.add(channel.notify(func() {
+			for _, notifier := range channel.returns {
+				notifier <- returned
+			}
+		}))
+	}
+
+	if channel.confirming {
+		channel.published++
+		confirmation := amqp.Confirmation{DeliveryTag: channel.published, Ack: accepted}
+		out.add(channel.notify(func() {
+			for _, notifier := range channel.publishes {
+				notifier <- confirmation
+			}
+		}))
+	}
+
+	return nil
+}
+
+//Get get a message of a queue, ok is false when the queue is empty
+func (channel *Channel) Get(queueName string, autoAck bool) (msg amqp.Delivery, ok bool, err error) {
+	err = channel.call("Get", func(out *outbox) error {
+		source, err := channel.broker.findQueue(queueName, channel.connection)
+		if err != nil {
+			return err
+		}
+
+		if len(source.messages) == 0 {
+			return nil
+		}
+
+		ready := source.messages[0]
+		source.messages = source.messages[1:]
+
+		channel.deliveryTag++
+		if !autoAck {
+			channel.unacked[channel.deliveryTag] = &unacked{tag: channel.deliveryTag, message: ready, queue: source}
+		}
+
+		msg = newDelivery(channel, ready, channel.deliveryTag, "", uint32(len(source.messages)))
+		ok = true
+
+		return nil
+	})
+
+	return msg, ok, err
+}
+
+//Consume start a consumer of a queue, an empty consumer tag generate a tag
+func (channel *Channel) Consume(queueName, consumerTag string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	var started *consumer
+	err := channel.call("Consume", func(out *outbox) error {
+		broker := channel.broker
+
+		var source *queue
+		var err error
+		if queueName == directReplyTo {
+			source, err = channel.replyTo(autoAck)
+		} else {
+			source, err = broker.findQueue(queueName, channel.connection)
+		}
+		if err != nil {
+			return err
+		}
+
+		if consumerTag == "" {
+			consumerTag = broker.nextName("ctag-amqppooltest")
+		}
+
+		if _, exist := channel.consumers[consumerTag]; exist {
+			return exception(amqp.NotAllowed, "attempt to reuse consumer tag '%v'", consumerTag)
+		}
+
+		for _, existing := range source.consumers {
+			if existing.exclusive || exclusive {
+				return exception(amqp.AccessRefused, "queue '%v' in vhost '/' in exclusive use", queueName)
+			}
+		}
+
+		started = &consumer{
+			tag:        consumerTag,
+			channel:    channel,
+			queue:      source,
+			autoAck:    autoAck,
+			exclusive:  exclusive,
+			signal:     make(chan struct{}, 1),
+			done:       make(chan struct{}),
+			deliveries: make(chan amqp.Delivery),
+		}
+		channel.consumers[consumerTag] = started
+		source.consumers = append(source.consumers, started)
+		source.consumed = true
+
+		go started.run(&broker.mutex)
+		broker.dispatch(source, out)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return started.deliveries, nil
+}
+
+//directReplyTo the pseudo-queue consumed to receive the replies sent to the ReplyTo of the messages published in
+//the same channel, without declare a queue
+const directReplyTo = "amq.rabbitmq.reply-to"
+
+//replyTo create the pseudo-queue of the consumer of direct reply-to of the channel, routed by the default exchange
+//and deleted with the consumer, the lock of the broker must be held
+func (channel *Channel) replyTo(autoAck bool) (*queue, error) {
+	if !autoAck {
+		return nil, exception(amqp.PreconditionFailed, "reply consumer cannot acknowledge")
+	}
+
+	if channel.replyQueue != nil {
+		return nil, exception(amqp.PreconditionFailed, "reply consumer already set")
+	}
+
+	broker := channel.broker
+	channel.replyQueue = &queue{
+		name:       broker.nextName(directReplyTo),
+		autoDelete: true,
+		exclusive:  true,
+		owner:      channel.connection,
+	}
+	broker.queues[channel.replyQueue.name] = channel.replyQueue
+
+	return channel.replyQueue, nil
+}
+
+//Cancel stop a consumer, closing its go channel of deliveries
+func (channel *Channel) Cancel(consumerTag string, noWait bool) error {
+	return channel.call("Cancel", func(out *outbox) error {
+		if cancelled, exist := channel.consumers[consumerTag]; exist {
+			channel.removeConsumer(cancelled, false, out)
+		}
+
+		return nil
+	})
+}
+
+//removeConsumer remove a consumer of its queue, notifying the listeners of cancel when was by the broker
+func (channel *Channel) removeConsumer(cancelled *consumer, byBroker bool, out *outbox) {
+	delete(channel.consumers, cancelled.tag)
+
+	source := cancelled.queue
+	consumers := make([]*consumer, 0, len(source.consumers))
+	for _, existing := range source.consumers {
+		if existing != cancelled {
+			consumers = append(consumers, existing)
+		}
+	}
+	source.consumers = consumers
+	close(cancelled.done)
+
+	if source == channel.replyQueue {
+		channel.replyQueue = nil
+	}
+
+	if byBroker {
+		out.add(channel.notify(func() {
+			for _, notifier := range channel.cancels {
+				notifier <- cancelled.tag
+			}
+		}))
+		return
+	}
+
+	broker := channel.broker
+	if source.autoDelete && source.consumed && len(source.consumers) == 0 && broker.queues[source.name] == source {
+		broker.deleteQueue(source, out)
+	}
+}
+
+//Ack acknowledge a delivery or, when multiple, all until the tag
+func (channel *Channel) Ack(tag uint64, multiple bool) error {
+	return channel.settle("Ack", tag, multiple, func(entries []*unacked, out *outbox) {})
+}
+
+//Nack reject a delivery or, when multiple, all until the tag, requeuing or dead-lettering them
+func (channel *Channel) Nack(tag uint64, multiple bool, requeue bool) error {
+	return channel.settle("Nack", tag, multiple, func(entries []*unacked, out *outbox) {
+		channel.reject(entries, requeue, out)
+	})
+}
+
+//Reject reject a delivery, requeuing or dead-lettering it
+func (channel *Channel) Reject(tag uint64, requeue bool) error {
+	return channel.settle("Reject", tag, false, func(entries []*unacked, out *outbox) {
+		channel.reject(entries, requeue, out)
+	})
+}
+
+//Recover requeue all unacked deliveries of the channel
+func (channel *Channel) Recover(requeue bool) error {
+	return channel.call("Recover", func(out *outbox) error {
+		if !requeue {
+			return exception(amqp.NotImplemented, "requeue=false")
+		}
+
+		channel.requeue(channel.unackedTags(0, true), out)
+		return nil
+	})
+}
+
+//settle remove the unacked deliveries of the tags and apply the action, failing with unknown tags
+func (channel *Channel) settle(method string, tag uint64, multiple bool, action func(entries []*unacked, out *outbox)) error {
+	return channel.call(method, func(out *outbox) error {
+		return channel.transact(func(out *outbox) error {
+			tags := channel.unackedTags(tag, multiple)
+			if len(tags) == 0 {
+				return exception(amqp.PreconditionFailed, "unknown delivery tag %v", tag)
+			}
+
+			entries := channel.removeUnacked(tags)
+			action(entries, out)
+
+			for _, entry := range entries {
+				if channel.broker.queues[entry.queue.name] == entry.queue {
+					channel.broker.dispatch(entry.queue, out)
+				}
+			}
+
+			return nil
+		}, out)
+	})
+}
+
+//unackedTags get the tags of the unacked deliveries, all until the tag when multiple or all when the tag is 0
+func (channel *Channel) unackedTags(tag uint64, multiple bool) []uint64 {
+	if !multiple {
+		if _, exist := channel.unacked[tag]; exist {
+			return []uint64{tag}
+		}
+		return nil
+	}
+
+	var tags []uint64
+	for unackedTag := range channel.unacked {
+		if tag == 0 || unackedTag <= tag {
+			tags = append(tags, unackedTag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+
+	return tags
+}
+
+//removeUnacked remove the unacked deliveries of the tags
+func (channel *Channel) removeUnacked(tags []uint64) []*unacked {
+	entries := make([]*unacked, 0, len(tags))
+	for _, tag := range tags {
+		entry := channel.unacked[tag]
+		delete(channel.unacked, tag)
+		if entry.consumer != nil {
+			entry.consumer.unacked--
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+//requeue return the unacked deliveries of the tags to the head of their queues
+func (channel *Channel) requeue(tags []uint64, out *outbox) {
+	entries := channel.removeUnacked(tags)
+	channel.reject(entries, true, out)
+
+	for _, entry := range entries {
+		if channel.broker.queues[entry.queue.name] == entry.queue {
+			channel.broker.dispatch(entry.queue, out)
+		}
+	}
+}
+
+//reject requeue, keeping the order, or dead-letter the deliveries
+func (channel *Channel) reject(entries []*unacked, requeue bool, out *outbox) {
+	broker := channel.broker
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if broker.queues[entry.queue.name] != entry.queue {
+			continue
+		}
+
+		if requeue {
+			entry.message.redelivered = true
+			entry.queue.messages = append([]*message{entry.message}, entry.queue.messages...)
+		}
+	}
+
+	if !requeue {
+		for _, entry := range entries {
+			if broker.queues[entry.queue.name] == entry.queue {
+				broker.deadLetter(entry.queue, entry.message, "rejected", out)
+			}
+		}
+	}
+}
+
+//hasCapacity indicates if the consumer can receive a delivery respecting the prefetch
+func (consumer *consumer) hasCapacity() bool {
+	if consumer.autoAck {
+		return true
+	}
+
+	channel := consumer.channel
+	if channel.prefetchCount > 0 && consumer.unacked >= channel.prefetchCount {
+		return false
+	}
+
+	return channel.globalCount == 0 || len(channel.unacked) < channel.globalCount
+}
+
+//deliver register a message how delivered to the consumer and signal it to be forwarded
+func (consumer *consumer) deliver(source *queue, ready *message) {
+	channel := consumer.channel
+	channel.deliveryTag++
+
+	if !consumer.autoAck {
+		channel.unacked[channel.deliveryTag] = &unacked{tag: channel.deliveryTag, message: ready, queue: source, consumer: consumer}
+		consumer.unacked++
+	}
+
+	consumer.pending = append(consumer.pending, newDelivery(channel, ready, channel.deliveryTag, consumer.tag, 0))
+
+	select {
+	case consumer.signal <- struct{}{}:
+	default:
+	}
+}
+
+//run forward the pending deliveries to the go channel until the consumer is cancelled
+func (consumer *consumer) run(mutex *sync.Mutex) {
+	defer close(consumer.deliveries)
+
+	for {
+		select {
+		case <-consumer.done:
+			return
+		default:
+		}
+
+		mutex.Lock()
+		if len(consumer.pending) == 0 {
+			mutex.Unlock()
+
+			select {
+			case <-consumer.signal:
+				continue
+			case <-consumer.done:
+				return
+			}
+		}
+
+		delivery := consumer.pending[0]
+		consumer.pending = consumer.pending[1:]
+		mutex.Unlock()
+
+		select {
+		case consumer.deliveries <- delivery:
+		case <-consumer.done:
+			return
+		}
+	}
+}
+
+//newDelivery create a delivery of a message
+func newDelivery(channel *Channel, delivered *message, tag uint64, consumerTag string, messageCount uint32) amqp.Delivery {
+	publishing := delivered.publishing
+
+	return amqp.Delivery{
+		Acknowledger:    channel,
+		Headers:         copyTable(publishing.Headers),
+		ContentType:     publishing.ContentType,
+		ContentEncoding: publishing.ContentEncoding,
+		DeliveryMode:    publishing.DeliveryMode,
+		Priority:        publishing.Priority,
+		CorrelationId:   publishing.CorrelationId,
+		ReplyTo:         publishing.ReplyTo,
+		Expiration:      publishing.Expiration,
+		MessageId:       publishing.MessageId,
+		Timestamp:       publishing.Timestamp,
+		Type:            publishing.Type,
+		UserId:          publishing.UserId,
+		AppId:           publishing.AppId,
+		ConsumerTag:     consumerTag,
+		MessageCount:    messageCount,
+		DeliveryTag:     tag,
+		Redelivered:     delivered.redelivered,
+		Exchange:        delivered.exchange,
+		RoutingKey:      delivered.key,
+		Body:            append([]byte(nil), publishing.Body...),
+	}
+}
+
+//newReturn create a return of a mandatory message that couldn't be routed
+func newReturn(exchangeName, key string, publishing amqp.Publishing) amqp.Return {
+	return amqp.Return{
+		ReplyCode:       amqp.NoRoute,
+		ReplyText:       "NO_ROUTE",
+		Exchange:        exchangeName,
+		RoutingKey:      key,
+		ContentType:     publishing.ContentType,
+		ContentEncoding: publishing.ContentEncoding,
+		Headers:         publishing.Headers,
+		DeliveryMode:    publishing.DeliveryMode,
+		Priority:        publishing.Priority,
+		CorrelationId:   publishing.CorrelationId,
+		ReplyTo:         publishing.ReplyTo,
+		Expiration:      publishing.Expiration,
+		MessageId:       publishing.MessageId,
+		Timestamp:       publishing.Timestamp,
+		Type:            publishing.Type,
+		UserId:          publishing.UserId,
+		AppId:           publishing.AppId,
+		Body:            publishing.Body,
+	}
+}
@@ -0,0 +1,147 @@
+package amqppooltest
+
+import (
+	"sync"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/streadway/amqp"
+)
+
+//Connection represents a connection with the in-memory broker, implementing amqppool.Connection
+type Connection struct {
+	broker         *Broker              //the broker of the connection
+	closed         bool                 //indicates when the connection was closed
+	channels       map[uint16]*Channel  //channels open by number
+	nextChannel    uint16               //last number of channel allocated
+	done           chan struct{}        //closed when the connection is closed
+	notifyMutex    sync.Mutex           //guard the notifications of close and blocking
+	notifyClosed   bool                 //indicates when the listeners were closed
+	closeNotifiers []chan *amqp.Error   //listeners of when the connection is closed
+	blockNotifiers []chan amqp.Blocking //listeners of when the connection is blocked or unblocked
+}
+
+//Channel open a new channel in the connection
+func (connection *Connection) Channel() (amqppool.Channel, error) {
+	broker := connection.broker
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	if connection.closed {
+		return nil, amqp.ErrClosed
+	}
+
+	connection.nextChannel++
+	channel := &Channel{
+		broker:     broker,
+		connection: connection,
+		id:         connection.nextChannel,
+		unacked:    make(map[uint64]*unacked),
+		consumers:  make(map[string]*consumer),
+	}
+	connection.channels[channel.id] = channel
+
+	return channel, nil
+}
+
+//NotifyClose register a listener for when the connection is closed, receiving the exception when was not by Close
+func (connection *Connection) NotifyClose(receiver chan *amqp.Error) chan *amqp.Error {
+	connection.notifyMutex.Lock()
+	defer connection.notifyMutex.Unlock()
+
+	if connection.notifyClosed {
+		close(receiver)
+	} else {
+		connection.closeNotifiers = append(connection.closeNotifiers, receiver)
+	}
+
+	return receiver
+}
+
+//NotifyBlocked register a listener for when the broker block or unblock the publishes of the connection
+func (connection *Connection) NotifyBlocked(receiver chan amqp.Blocking) chan amqp.Blocking {
+	connection.notifyMutex.Lock()
+	defer connection.notifyMutex.Unlock()
+
+	if connection.notifyClosed {
+		close(receiver)
+	} else {
+		connection.blockNotifiers = append(connection.blockNotifiers, receiver)
+	}
+
+	return receiver
+}
+
+//Close close the connection and its channels
+func (connection *Connection) Close() error {
+	broker := connection.broker
+	out := &outbox{}
+
+	broker.mutex.Lock()
+	if connection.closed {
+		broker.mutex.Unlock()
+		return amqp.ErrClosed
+	}
+	connection.shutdown(nil, out)
+	broker.mutex.Unlock()
+
+	out.flush()
+
+	return nil
+}
+
+//IsClosed indicates if the connection was closed
+func (connection *Connection) IsClosed() bool {
+	connection.broker.mutex.Lock()
+	defer connection.broker.mutex.Unlock()
+
+	return connection.closed
+}
+
+//shutdown close the channels, delete the exclusive queues and notify the listeners with the exception, if any
+func (connection *Connection) shutdown(err *amqp.Error, out *outbox) {
+	broker := connection.broker
+	connection.closed = true
+	close(connection.done)
+	delete(broker.connections, connection)
+
+	for _, channel := range connection.channels {
+		channel.shutdown(err, out)
+	}
+
+	for _, declared := range broker.queues {
+		if declared.exclusive && declared.owner == connection {
+			broker.deleteQueue(declared, out)
+		}
+	}
+
+	out.add(func() {
+		connection.notifyMutex.Lock()
+		defer connection.notifyMutex.Unlock()
+
+		connection.notifyClosed = true
+		for _, notifier := range connection.closeNotifiers {
+			if err != nil {
+				notifier <- err
+			}
+			close(notifier)
+		}
+		for _, notifier := range connection.blockNotifiers {
+			close(notifier)
+		}
+		connection.closeNotifiers, connection.blockNotifiers = nil, nil
+	})
+}
+
+//notifyBlocked send a notification of blocking to the listeners while they are open
+func (connection *Connection) notifyBlocked(blocking amqp.Blocking) func() {
+	return func() {
+		connection.notifyMutex.Lock()
+		defer connection.notifyMutex.Unlock()
+
+		if !connection.notifyClosed {
+			for _, notifier := range connection.blockNotifiers {
+				notifier <- blocking
+			}
+		}
+	}
+}
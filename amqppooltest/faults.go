@@ -0,0 +1,183 @@
+package amqppooltest
+
+import (
+	"errors"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+//KillConnections close all connections open with a connection exception of the broker, how when RabbitMQ is
+//stopped or a connection is closed by the management, e.g. amqp.ConnectionForced
+func (broker *Broker) KillConnections(code int, reason string) {
+	out := &outbox{}
+
+	broker.mutex.Lock()
+	for connection := range broker.connections {
+		connection.shutdown(exception(code, "%v", reason), out)
+	}
+	broker.mutex.Unlock()
+
+	out.flush()
+}
+
+//TimeoutHeartbeats close all connections open how when the heartbeats of the broker are lost, with the error
+//created by the client instead of an exception of the broker
+func (broker *Broker) TimeoutHeartbeats() {
+	out := &outbox{}
+
+	broker.mutex.Lock()
+	for connection := range broker.connections {
+		connection.shutdown(&amqp.Error{
+			Code:   amqp.FrameError,
+			Reason: "read tcp 127.0.0.1:52400->127.0.0.1:5672: i/o timeout",
+		}, out)
+	}
+	broker.mutex.Unlock()
+
+	out.flush()
+}
+
+//CloseChannels close all channels open with a channel exception of the broker, e.g. amqp.PreconditionFailed
+func (broker *Broker) CloseChannels(code int, reason string) {
+	out := &outbox{}
+
+	broker.mutex.Lock()
+	for connection := range broker.connections {
+		for _, channel := range connection.channels {
+			channel.shutdown(exception(code, "%v", reason), out)
+		}
+	}
+	broker.mutex.Unlock()
+
+	out.flush()
+}
+
+//CancelConsumers cancel all consumers by the broker, how when the node of their queue is down, notifying the
+//listeners of cancel of their channels, which stay open
+func (broker *Broker) CancelConsumers() {
+	out := &outbox{}
+
+	broker.mutex.Lock()
+	for connection := range broker.connections {
+		for _, channel := range connection.channels {
+			for _, cancelled := range channel.consumers {
+				channel.removeConsumer(cancelled, true, out)
+			}
+		}
+	}
+	broker.mutex.Unlock()
+
+	out.flush()
+}
+
+//InjectChannelException fail the next call of a method of the channels with an exception, the method is the name
+//of the method of amqppool.Channel, e.g. "QueueDeclare". The exception close the channel or the connection
+//according to the code, like it was raised by the broker
+func (broker *Broker) InjectChannelException(method string, code int, reason string) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	broker.faults[method] = exception(code, "%v", reason)
+}
+
+//takeFault remove and get the exception injected in a method, the lock of the broker must be held
+func (broker *Broker) takeFault(method string) *amqp.Error {
+	injected, exist := broker.faults[method]
+	if !exist {
+		return nil
+	}
+
+	delete(broker.faults, method)
+	return injected
+}
+
+//SetLatency add a latency to each operation of the channels, 0 remove it
+func (broker *Broker) SetLatency(latency time.Duration) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	broker.latency = latency
+}
+
+//delay wait the latency configured
+func (broker *Broker) delay() {
+	broker.mutex.Lock()
+	latency := broker.latency
+	broker.mutex.Unlock()
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+}
+
+//RefuseConnections fail the next dials with the error until AcceptConnections, without affect the connections open
+func (broker *Broker) RefuseConnections(err error) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	if err == nil {
+		err = errors.New("dial tcp 127.0.0.1:5672: connect: connection refused")
+	}
+	broker.refusal = err
+}
+
+//AcceptConnections accept again the dials refused by RefuseConnections
+func (broker *Broker) AcceptConnections() {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	broker.refusal = nil
+}
+
+//Block block the publishes of all connections, how when a resource alarm of RabbitMQ is raised, notifying the
+//listeners of NotifyBlocked. The publishes wait until Unblock or the connection is closed
+func (broker *Broker) Block(reason string) {
+	out := &outbox{}
+
+	broker.mutex.Lock()
+	if broker.unblocked == nil {
+		broker.unblocked = make(chan struct{})
+		for connection := range broker.connections {
+			out.add(connection.notifyBlocked(amqp.Blocking{Active: true, Reason: reason}))
+		}
+	}
+	broker.mutex.Unlock()
+
+	out.flush()
+}
+
+//Unblock unblock the publishes of all connections, notifying the listeners of NotifyBlocked
+func (broker *Broker) Unblock() {
+	out := &outbox{}
+
+	broker.mutex.Lock()
+	if broker.unblocked != nil {
+		close(broker.unblocked)
+		broker.unblocked = nil
+		for connection := range broker.connections {
+			out.add(connection.notifyBlocked(amqp.Blocking{Active: false}))
+		}
+	}
+	broker.mutex.Unlock()
+
+	out.flush()
+}
+
+//waitUnblocked wait while the broker is blocked, failing when the connection is closed
+func (broker *Broker) waitUnblocked(connection *Connection) error {
+	broker.mutex.Lock()
+	unblocked := broker.unblocked
+	broker.mutex.Unlock()
+
+	if unblocked == nil {
+		return nil
+	}
+
+	select {
+	case <-unblocked:
+		return nil
+	case <-connection.done:
+		return amqp.ErrClosed
+	}
+}
@@ -0,0 +1,188 @@
+package amqppooltest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+func TestShouldKillTheConnectionsWithTheException(t *testing.T) {
+	//Arrange
+	broker := NewBroker()
+	connection, _ := broker.Dial(connectionString)
+	closes := connection.NotifyClose(make(chan *amqp.Error, 1))
+
+	//Action
+	broker.KillConnections(amqp.ConnectionForced, "broker forced connection closure with reason 'shutdown'")
+
+	//Assert
+	if closed := <-closes; closed == nil || closed.Code != amqp.ConnectionForced || !closed.Server {
+		t.Errorf("The connection was not closed with CONNECTION_FORCED: %v", closed)
+	}
+
+	if broker.ConnectionCount() != 0 {
+		t.Errorf("The connection still open in the broker")
+	}
+}
+
+func TestShouldCloseTheConnectionsWhenTheHeartbeatsTimeout(t *testing.T) {
+	//Arrange
+	broker := NewBroker()
+	connection, _ := broker.Dial(connectionString)
+	closes := connection.NotifyClose(make(chan *amqp.Error, 1))
+
+	//Action
+	broker.TimeoutHeartbeats()
+
+	//Assert
+	if closed := <-closes; closed == nil || closed.Code != amqp.FrameError || closed.Server {
+		t.Errorf("The connection was not closed by the client with FRAME_ERROR: %v", closed)
+	}
+}
+
+func TestShouldCancelTheConsumersKeepingTheChannelsOpen(t *testing.T) {
+	//Arrange
+	broker := NewBroker()
+	channel := openChannel(t, broker)
+	cancels := channel.NotifyCancel(make(chan string, 1))
+	_, _ = channel.QueueDeclare("orders", false, false, false, false, nil)
+	deliveries, _ := channel.Consume("orders", "orders-consumer", true, false, false, false, nil)
+
+	//Action
+	broker.CancelConsumers()
+
+	//Assert
+	if tag := <-cancels; tag != "orders-consumer" {
+		t.Errorf("The tag of the consumer cancelled is different of expected: %v", tag)
+	}
+
+	if _, open := <-deliveries; open {
+		t.Error("The deliveries of the consumer cancelled were not closed")
+	}
+
+	if _, err := channel.QueueInspect("orders"); err != nil {
+		t.Errorf("The channel was closed with the consumer: %v", err)
+	}
+}
+
+func TestShouldFailTheNextCallOfTheMethodWithTheExceptionInjected(t *testing.T) {
+	//Arrange
+	broker := NewBroker()
+	channel := openChannel(t, broker)
+	closes := channel.NotifyClose(make(chan *amqp.Error, 1))
+
+	broker.InjectChannelException("QueueDeclare", amqp.PreconditionFailed, "injected")
+
+	//Action
+	_, err := channel.QueueDeclare("orders", false, false, false, false, nil)
+
+	//Assert
+	var amqpErr *amqp.Error
+	if !errors.As(err, &amqpErr) || amqpErr.Code != amqp.PreconditionFailed {
+		t.Fatalf("The error returned is different of expected: %v", err)
+	}
+
+	if closed := <-closes; closed != amqpErr {
+		t.Errorf("The channel was not closed with the exception injected: %v", closed)
+	}
+
+	other := openChannel(t, broker)
+	if _, err := other.QueueDeclare("orders", false, false, false, false, nil); err != nil {
+		t.Errorf("The exception injected was raised more than once: %v", err)
+	}
+}
+
+func TestShouldCloseTheChannelsWithTheException(t *testing.T) {
+	//Arrange
+	broker := NewBroker()
+	connection, _ := broker.Dial(connectionString)
+	channel, _ := connection.Channel()
+	closes := channel.NotifyClose(make(chan *amqp.Error, 1))
+
+	//Action
+	broker.CloseChannels(amqp.NotFound, "injected")
+
+	//Assert
+	if closed := <-closes; closed == nil || closed.Code != amqp.NotFound {
+		t.Errorf("The channel was not closed with NOT_FOUND: %v", closed)
+	}
+
+	if _, err := connection.Channel(); err != nil {
+		t.Errorf("The connection was closed with the channels: %v", err)
+	}
+}
+
+func TestShouldRefuseTheConnectionsUntilAcceptThem(t *testing.T) {
+	//Arrange
+	broker := NewBroker()
+	refusal := errors.New("connection refused")
+
+	//Action
+	broker.RefuseConnections(refusal)
+	_, refused := broker.Dial(connectionString)
+	broker.AcceptConnections()
+	_, accepted := broker.Dial(connectionString)
+
+	//Assert
+	if refused != refusal {
+		t.Errorf("The dial was not refused with the error: %v", refused)
+	}
+
+	if accepted != nil {
+		t.Errorf("The dial was not accepted: %v", accepted)
+	}
+}
+
+func TestShouldBlockThePublishesUntilUnblock(t *testing.T) {
+	//Arrange
+	broker := NewBroker()
+	dialed, _ := broker.Dial(connectionString)
+	connection := dialed.(*Connection)
+	blockings := connection.NotifyBlocked(make(chan amqp.Blocking, 2))
+	channel, _ := connection.Channel()
+	_, _ = channel.QueueDeclare("orders", false, false, false, false, nil)
+
+	//Action
+	broker.Block("low on memory")
+
+	published := make(chan error)
+	go func() {
+		published <- channel.Publish("", "orders", false, false, amqp.Publishing{})
+	}()
+
+	//Assert
+	select {
+	case <-published:
+		t.Fatal("The publish was not blocked")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	broker.Unblock()
+
+	if err := <-published; err != nil || broker.MessageCount("orders") != 1 {
+		t.Errorf("The publish was not done after unblock: %v", err)
+	}
+
+	if blocked, unblocked := <-blockings, <-blockings; !blocked.Active || blocked.Reason != "low on memory" || unblocked.Active {
+		t.Errorf("The blockings notified are inconsistent: %+v and %+v", blocked, unblocked)
+	}
+}
+
+func TestShouldAddTheLatencyToTheOperations(t *testing.T) {
+	//Arrange
+	broker := NewBroker()
+	channel := openChannel(t, broker)
+	broker.SetLatency(20 * time.Millisecond)
+
+	//Action
+	start := time.Now()
+	_, _ = channel.QueueDeclare("orders", false, false, false, false, nil)
+	elapsed := time.Since(start)
+
+	//Assert
+	if elapsed < 20*time.Millisecond {
+		t.Errorf("The latency was not added to the operation: %v", elapsed)
+	}
+}
@@ -0,0 +1,41 @@
+//Package amqppoolzap adapt a zap logger to amqppool.Logger:
+//
+//	pool, err := amqppool.NewPool(connectionString, 10, amqppool.WithLogger(amqppoolzap.NewLogger(logger)))
+package amqppoolzap
+
+import (
+	"github.com/gmarcial/amqppool"
+	"go.uber.org/zap"
+)
+
+//Logger adapt a *zap.Logger to amqppool.Logger
+type Logger struct {
+	logger *zap.SugaredLogger //logger of the events
+}
+
+var _ amqppool.Logger = (*Logger)(nil)
+
+//NewLogger create a new Logger
+func NewLogger(logger *zap.Logger) *Logger {
+	return &Logger{logger: logger.Sugar()}
+}
+
+//Debug implementing the amqppool.Logger interface
+func (logger *Logger) Debug(msg string, keysAndValues ...interface{}) {
+	logger.logger.Debugw(msg, keysAndValues...)
+}
+
+//Info implementing the amqppool.Logger interface
+func (logger *Logger) Info(msg string, keysAndValues ...interface{}) {
+	logger.logger.Infow(msg, keysAndValues...)
+}
+
+//Warn implementing the amqppool.Logger interface
+func (logger *Logger) Warn(msg string, keysAndValues ...interface{}) {
+	logger.logger.Warnw(msg, keysAndValues...)
+}
+
+//Error implementing the amqppool.Logger interface
+func (logger *Logger) Error(msg string, keysAndValues ...interface{}) {
+	logger.logger.Errorw(msg, keysAndValues...)
+}
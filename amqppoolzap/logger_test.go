@@ -0,0 +1,31 @@
+package amqppoolzap
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestShouldLogTheEventsInTheZapLogger(t *testing.T) {
+	//Arrange
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := NewLogger(zap.New(core))
+
+	//Action
+	logger.Debug("reusable channel released", "channel_id", 1)
+	logger.Warn("failed to reconnect with the broker", "attempt", 1, "error", "connection refused")
+
+	//Assert
+	entries := logs.AllUntimed()
+	if len(entries) != 1 {
+		t.Fatalf("The quantity of events logged is inconsistent: Expected 1 and found %v", len(entries))
+	}
+
+	fields := entries[0].ContextMap()
+	if entries[0].Level != zapcore.WarnLevel || entries[0].Message != "failed to reconnect with the broker" ||
+		fields["attempt"] != int64(1) || fields["error"] != "connection refused" {
+		t.Errorf("The event logged is inconsistent: %+v", entries[0])
+	}
+}
@@ -0,0 +1,50 @@
+//Package amqppoolzerolog adapt a zerolog logger to amqppool.Logger:
+//
+//	pool, err := amqppool.NewPool(connectionString, 10, amqppool.WithLogger(amqppoolzerolog.NewLogger(logger)))
+package amqppoolzerolog
+
+import (
+	"github.com/gmarcial/amqppool"
+	"github.com/rs/zerolog"
+)
+
+//Logger adapt a zerolog.Logger to amqppool.Logger
+type Logger struct {
+	logger zerolog.Logger //logger of the events
+}
+
+var _ amqppool.Logger = (*Logger)(nil)
+
+//NewLogger create a new Logger
+func NewLogger(logger zerolog.Logger) *Logger {
+	return &Logger{logger: logger}
+}
+
+//Debug implementing the amqppool.Logger interface
+func (logger *Logger) Debug(msg string, keysAndValues ...interface{}) {
+	log(logger.logger.Debug(), msg, keysAndValues)
+}
+
+//Info implementing the amqppool.Logger interface
+func (logger *Logger) Info(msg string, keysAndValues ...interface{}) {
+	log(logger.logger.Info(), msg, keysAndValues)
+}
+
+//Warn implementing the amqppool.Logger interface
+func (logger *Logger) Warn(msg string, keysAndValues ...interface{}) {
+	log(logger.logger.Warn(), msg, keysAndValues)
+}
+
+//Error implementing the amqppool.Logger interface
+func (logger *Logger) Error(msg string, keysAndValues ...interface{}) {
+	log(logger.logger.Error(), msg, keysAndValues)
+}
+
+//log write an event with the fields, the event is nil when its level is disabled
+func log(event *zerolog.Event, msg string, keysAndValues []interface{}) {
+	if event == nil {
+		return
+	}
+
+	event.Fields(keysAndValues).Msg(msg)
+}
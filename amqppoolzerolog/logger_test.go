@@ -0,0 +1,25 @@
+package amqppoolzerolog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestShouldLogTheEventsInTheZerologLogger(t *testing.T) {
+	//Arrange
+	output := &bytes.Buffer{}
+	logger := NewLogger(zerolog.New(output).Level(zerolog.InfoLevel))
+
+	//Action
+	logger.Debug("reusable channel released", "channel_id", 1)
+	logger.Error("gave up of reconnect with the broker", "attempts", 3)
+
+	//Assert
+	expected := `{"level":"error","attempts":3,"message":"gave up of reconnect with the broker"}`
+	if line := strings.TrimSpace(output.String()); line != expected {
+		t.Errorf("The events logged are inconsistent: Expected %v and found %v", expected, line)
+	}
+}
@@ -0,0 +1,302 @@
+package amqppool
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+//QueueType the type of a queue, declared by the argument x-queue-type
+type QueueType string
+
+const (
+	//QueueTypeClassic the classic queue, the default of the broker when x-queue-type is not declared
+	QueueTypeClassic QueueType = "classic"
+	//QueueTypeQuorum the replicated queue based on Raft, always durable
+	QueueTypeQuorum QueueType = "quorum"
+	//QueueTypeStream the replicated append-only log, always durable and consumed with offsets
+	QueueTypeStream QueueType = "stream"
+)
+
+//Overflow the behaviour of a queue when the maximum length is reached, declared by the argument x-overflow
+type Overflow string
+
+const (
+	//OverflowDropHead drop or dead-letter the oldest messages, the default of the broker
+	OverflowDropHead Overflow = "drop-head"
+	//OverflowRejectPublish reject the new messages, nacking them when the publisher confirms are enabled
+	OverflowRejectPublish Overflow = "reject-publish"
+	//OverflowRejectPublishDLX reject the new messages and dead-letter them, only supported by classic queues
+	OverflowRejectPublishDLX Overflow = "reject-publish-dlx"
+)
+
+//unsupportedArguments the arguments which each type of queue refuse
+var unsupportedArguments = map[QueueType][]string{
+	QueueTypeClassic: {"x-delivery-limit", "x-max-age", "x-stream-max-segment-size-bytes", "x-initial-cluster-size"},
+	QueueTypeQuorum:  {"x-max-priority", "x-queue-mode", "x-max-age", "x-stream-max-segment-size-bytes"},
+	QueueTypeStream: {"x-max-length", "x-message-ttl", "x-expires", "x-overflow", "x-dead-letter-exchange",
+		"x-dead-letter-routing-key", "x-single-active-consumer", "x-max-priority", "x-queue-mode", "x-delivery-limit"},
+}
+
+//integerArguments the arguments which the broker only accept how integers
+var integerArguments = []string{"x-max-length", "x-max-length-bytes", "x-message-ttl", "x-expires", "x-max-priority",
+	"x-delivery-limit", "x-stream-max-segment-size-bytes", "x-initial-cluster-size"}
+
+//QueueArguments a builder of the arguments of a queue declaration, with typed values and validation of the combinations.
+//Each argument not set keep the default of the broker.
+type QueueArguments struct {
+	table amqp.Table //the arguments built
+}
+
+//NewClassicQueueArguments create a builder of arguments of a classic queue
+func NewClassicQueueArguments() *QueueArguments {
+	return &QueueArguments{table: amqp.Table{"x-queue-type": string(QueueTypeClassic)}}
+}
+
+//NewQuorumQueueArguments create a builder of arguments of a quorum queue,
+//which must be declared durable, not exclusive and not auto delete
+func NewQuorumQueueArguments() *QueueArguments {
+	return &QueueArguments{table: amqp.Table{"x-queue-type": string(QueueTypeQuorum)}}
+}
+
+//NewStreamQueueArguments create a builder of arguments of a stream,
+//which must be declared durable, not exclusive and not auto delete
+func NewStreamQueueArguments() *QueueArguments {
+	return &QueueArguments{table: amqp.Table{"x-queue-type": string(QueueTypeStream)}}
+}
+
+//MaxLength the maximum quantity of ready messages (x-max-length), by default unlimited
+func (arguments *QueueArguments) MaxLength(messages int64) *QueueArguments {
+	arguments.table["x-max-length"] = messages
+	return arguments
+}
+
+//MaxLengthBytes the maximum size in bytes of the bodies of ready messages (x-max-length-bytes), by default unlimited
+func (arguments *QueueArguments) MaxLengthBytes(bytes int64) *QueueArguments {
+	arguments.table["x-max-length-bytes"] = bytes
+	return arguments
+}
+
+//Overflow the behaviour when the maximum length is reached (x-overflow), by default drop-head
+func (arguments *QueueArguments) Overflow(overflow Overflow) *QueueArguments {
+	arguments.table["x-overflow"] = string(overflow)
+	return arguments
+}
+
+//DeadLetterExchange the exchange of the messages rejected, expired or dropped (x-dead-letter-exchange), by default
+//they are discarded
+func (arguments *QueueArguments) DeadLetterExchange(exchange string) *QueueArguments {
+	arguments.table["x-dead-letter-exchange"] = exchange
+	return arguments
+}
+
+//DeadLetterRoutingKey the routing key of the dead-lettered messages (x-dead-letter-routing-key), by default the
+//original routing key, requires a dead letter exchange
+func (arguments *QueueArguments) DeadLetterRoutingKey(key string) *QueueArguments {
+	arguments.table["x-dead-letter-routing-key"] = key
+	return arguments
+}
+
+//SingleActiveConsumer deliver to only one consumer at a time (x-single-active-consumer), by default disabled
+func (arguments *QueueArguments) SingleActiveConsumer(enabled bool) *QueueArguments {
+	arguments.table["x-single-active-consumer"] = enabled
+	return arguments
+}
+
+//MessageTTL the time of life of the messages, in milliseconds on the broker (x-message-ttl), by default unlimited
+func (arguments *QueueArguments) MessageTTL(ttl time.Duration) *QueueArguments {
+	arguments.table["x-message-ttl"] = ttl.Milliseconds()
+	return arguments
+}
+
+//Expires the time of a queue unused before is deleted, in milliseconds on the broker (x-expires), by default never
+func (arguments *QueueArguments) Expires(ttl time.Duration) *QueueArguments {
+	arguments.table["x-expires"] = ttl.Milliseconds()
+	return arguments
+}
+
+//MaxPriority the maximum priority of the messages (x-max-priority), by default the queue don't support priorities,
+//only classic queues, values until 10 are recommended by the broker
+func (arguments *QueueArguments) MaxPriority(priority uint8) *QueueArguments {
+	arguments.table["x-max-priority"] = int64(priority)
+	return arguments
+}
+
+//DeliveryLimit the quantity of redeliveries before the message is dead-lettered (x-delivery-limit), only quorum
+//queues, by default unlimited until RabbitMQ 4.0 and 20 since
+func (arguments *QueueArguments) DeliveryLimit(limit int64) *QueueArguments {
+	arguments.table["x-delivery-limit"] = limit
+	return arguments
+}
+
+//MaxAge the maximum age of the segments of a stream (x-max-age), with the units Y, M, D, h, m and s, e.g. "7D",
+//by default unlimited
+func (arguments *QueueArguments) MaxAge(age string) *QueueArguments {
+	arguments.table["x-max-age"] = age
+	return arguments
+}
+
+//Build validate the combination of the arguments and return the table to use in QueueDeclare,
+//the properties of the declaration are validated by ValidateQueueArguments
+func (arguments *QueueArguments) Build() (amqp.Table, error) {
+	if problems := queueArgumentsProblems(arguments.table); len(problems) > 0 {
+		return nil, &InvalidArgumentsError{Problems: problems}
+	}
+
+	table := make(amqp.Table, len(arguments.table))
+	for key, value := range arguments.table {
+		table[key] = value
+	}
+
+	return table, nil
+}
+
+//ValidateQueueArguments verify the arguments of a queue declaration and the combination with its properties
+func ValidateQueueArguments(args amqp.Table, durable, autoDelete, exclusive bool) error {
+	problems := queueArgumentsProblems(args)
+	problems = append(problems, queuePropertiesProblems(args, durable, autoDelete, exclusive)...)
+
+	if len(problems) > 0 {
+		return &InvalidArgumentsError{Problems: problems}
+	}
+
+	return nil
+}
+
+//queueTypeOf get the type of queue declared by the arguments, by default classic
+func queueTypeOf(args amqp.Table) QueueType {
+	if queueType, ok := args["x-queue-type"].(string); ok && queueType != "" {
+		return QueueType(queueType)
+	}
+
+	return QueueTypeClassic
+}
+
+//queueArgumentsProblems verify the values and combinations of the arguments of a queue
+func queueArgumentsProblems(args amqp.Table) []string {
+	var problems []string
+
+	if err := args.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	queueType := queueTypeOf(args)
+	unsupported, known := unsupportedArguments[queueType]
+	if !known {
+		problems = append(problems, fmt.Sprintf("unknown queue type %q", queueType))
+	}
+
+	for _, argument := range unsupported {
+		if _, exist := args[argument]; exist {
+			problems = append(problems, fmt.Sprintf("%v is not supported by %v queues", argument, queueType))
+		}
+	}
+
+	for _, argument := range integerArguments {
+		value, exist := args[argument]
+		if !exist {
+			continue
+		}
+
+		if integer, ok := toInt64(value); !ok || integer < 0 {
+			problems = append(problems, fmt.Sprintf("%v must be a non-negative integer", argument))
+		}
+	}
+
+	if value, exist := args["x-overflow"]; exist {
+		switch Overflow(fmt.Sprint(value)) {
+		case OverflowDropHead, OverflowRejectPublish:
+		case OverflowRejectPublishDLX:
+			if queueType == QueueTypeQuorum {
+				problems = append(problems, "x-overflow reject-publish-dlx is not supported by quorum queues")
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("unknown x-overflow %q", value))
+		}
+	}
+
+	if value, exist := args["x-single-active-consumer"]; exist {
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, "x-single-active-consumer must be a boolean")
+		}
+	}
+
+	_, hasDeadLetterExchange := args["x-dead-letter-exchange"]
+	if _, exist := args["x-dead-letter-routing-key"]; exist && !hasDeadLetterExchange {
+		problems = append(problems, "x-dead-letter-routing-key requires x-dead-letter-exchange")
+	}
+
+	return problems
+}
+
+//queuePropertiesProblems verify the combination of the type of queue with the properties of the declaration
+func queuePropertiesProblems(args amqp.Table, durable, autoDelete, exclusive bool) []string {
+	queueType := queueTypeOf(args)
+	if queueType != QueueTypeQuorum && queueType != QueueTypeStream {
+		return nil
+	}
+
+	var problems []string
+	if !durable {
+		problems = append(problems, fmt.Sprintf("%v queues must be durable", queueType))
+	}
+	if autoDelete {
+		problems = append(problems, fmt.Sprintf("%v queues can't be auto delete", queueType))
+	}
+	if exclusive {
+		problems = append(problems, fmt.Sprintf("%v queues can't be exclusive", queueType))
+	}
+
+	return problems
+}
+
+//toInt64 convert the integer types accepted by an amqp table
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case byte:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+//ExchangeArguments a builder of the arguments of an exchange declaration
+type ExchangeArguments struct {
+	table amqp.Table //the arguments built
+}
+
+//NewExchangeArguments create a builder of arguments of an exchange
+func NewExchangeArguments() *ExchangeArguments {
+	return &ExchangeArguments{table: amqp.Table{}}
+}
+
+//AlternateExchange the exchange of the messages that can't be routed (alternate-exchange), by default they are
+//discarded or returned when mandatory
+func (arguments *ExchangeArguments) AlternateExchange(exchange string) *ExchangeArguments {
+	arguments.table["alternate-exchange"] = exchange
+	return arguments
+}
+
+//Build validate and return the table to use in ExchangeDeclare
+func (arguments *ExchangeArguments) Build() (amqp.Table, error) {
+	if exchange, exist := arguments.table["alternate-exchange"]; exist && strings.TrimSpace(fmt.Sprint(exchange)) == "" {
+		return nil, &InvalidArgumentsError{Problems: []string{"alternate-exchange without name"}}
+	}
+
+	table := make(amqp.Table, len(arguments.table))
+	for key, value := range arguments.table {
+		table[key] = value
+	}
+
+	return table, nil
+}
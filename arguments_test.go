@@ -0,0 +1,88 @@
+package amqppool
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestShouldBuildTheArgumentsOfAQuorumQueue(t *testing.T) {
+	//Arrange
+	arguments := NewQuorumQueueArguments().
+		MaxLength(1000).
+		Overflow(OverflowRejectPublish).
+		DeadLetterExchange("orders.dead-letter").
+		SingleActiveConsumer(true).
+		MessageTTL(time.Minute)
+
+	//Action
+	table, err := arguments.Build()
+
+	//Assert
+	if err != nil {
+		t.Fatalf("Occurred a error to build the arguments: %v", err.Error())
+	}
+
+	if table["x-queue-type"] != "quorum" || table["x-max-length"] != int64(1000) || table["x-overflow"] != "reject-publish" ||
+		table["x-dead-letter-exchange"] != "orders.dead-letter" || table["x-single-active-consumer"] != true ||
+		table["x-message-ttl"] != int64(60000) {
+		t.Errorf("The arguments built are inconsistent: %v", table)
+	}
+
+	if err := table.Validate(); err != nil {
+		t.Errorf("The arguments built can't be encoded in a amqp table: %v", err.Error())
+	}
+}
+
+func TestShouldReturnErrorWhenBuildArgumentsNotSupportedByTheTypeOfQueue(t *testing.T) {
+	//Arrange
+	arguments := NewStreamQueueArguments().
+		MaxLengthBytes(1 << 30).
+		MessageTTL(time.Minute).
+		DeadLetterRoutingKey("dead")
+
+	//Action
+	table, err := arguments.Build()
+
+	//Assert
+	if table != nil {
+		t.Error("The arguments were built same being invalid")
+	}
+
+	var invalidArgumentsError *InvalidArgumentsError
+	if !errors.As(err, &invalidArgumentsError) {
+		t.Fatalf("The type of error returned is different of expected: %v", err)
+	}
+
+	if len(invalidArgumentsError.Problems) != 3 {
+		t.Errorf("The quantity of problems is inconsistent: Expected 3 and found %v: %v",
+			len(invalidArgumentsError.Problems), invalidArgumentsError.Problems)
+	}
+}
+
+func TestShouldReturnErrorWhenValidateAQuorumQueueExclusive(t *testing.T) {
+	//Arrange
+	table, _ := NewQuorumQueueArguments().Build()
+
+	//Action
+	err := ValidateQueueArguments(table, true, false, true)
+
+	//Assert
+	var invalidArgumentsError *InvalidArgumentsError
+	if !errors.As(err, &invalidArgumentsError) {
+		t.Fatalf("The type of error returned is different of expected: %v", err)
+	}
+}
+
+func TestShouldReturnErrorWhenValidateAnOverflowNotSupportedByQuorumQueues(t *testing.T) {
+	//Arrange
+	table := NewQuorumQueueArguments().Overflow(OverflowRejectPublishDLX).table
+
+	//Action
+	err := ValidateQueueArguments(table, true, false, false)
+
+	//Assert
+	if err == nil {
+		t.Error("Don't occurred a error to validate the overflow reject-publish-dlx in a quorum queue")
+	}
+}
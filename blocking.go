@@ -0,0 +1,139 @@
+package amqppool
+
+import (
+	"context"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+//blockage track when the broker blocked the publishes of the connection, shared by the pool and its reusable channels
+type blockage struct {
+	mutex     sync.Mutex    //guard the state of the block
+	reason    string        //the reason of the block informed by the broker
+	unblocked chan struct{} //closed when the connection is unblocked, nil while not blocked
+}
+
+//block mark the connection how blocked
+func (blockage *blockage) block(reason string) {
+	blockage.mutex.Lock()
+	defer blockage.mutex.Unlock()
+
+	blockage.reason = reason
+	if blockage.unblocked == nil {
+		blockage.unblocked = make(chan struct{})
+	}
+}
+
+//unblock mark the connection how unblocked, releasing the publishes waiting
+func (blockage *blockage) unblock() {
+	blockage.mutex.Lock()
+	defer blockage.mutex.Unlock()
+
+	if blockage.unblocked != nil {
+		close(blockage.unblocked)
+		blockage.unblocked = nil
+		blockage.reason = ""
+	}
+}
+
+//check fail with a ConnectionBlockedError while the connection is blocked
+func (blockage *blockage) check() error {
+	blockage.mutex.Lock()
+	defer blockage.mutex.Unlock()
+
+	if blockage.unblocked != nil {
+		return &ConnectionBlockedError{Reason: blockage.reason}
+	}
+
+	return nil
+}
+
+//wait wait while the connection is blocked, failing with a ConnectionBlockedError when the context is done before
+func (blockage *blockage) wait(ctx context.Context) error {
+	blockage.mutex.Lock()
+	unblocked := blockage.unblocked
+	reason := blockage.reason
+	blockage.mutex.Unlock()
+
+	if unblocked == nil {
+		return nil
+	}
+
+	select {
+	case <-unblocked:
+		return nil
+	case <-ctx.Done():
+		return &ConnectionBlockedError{Reason: reason, Err: ctx.Err()}
+	}
+}
+
+//IsBlocked indicates if the broker blocked the publishes of the connection, e.g. by a memory or disk alarm
+func (pool *Pool) IsBlocked() bool {
+	return pool.blockage.check() != nil
+}
+
+//Publish get a reusable channel, waiting one be released, and publish a message on it, waiting while the connection
+//is blocked until the context is done
+func (pool *Pool) Publish(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	reusableChannel, err := pool.GetReusableChannelContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer reusableChannel.Release()
+
+	return reusableChannel.PublishContext(ctx, exchange, key, mandatory, immediate, msg)
+}
+
+//PublishConfirm publish a message how Publish in confirm mode, waiting the broker confirm it until the context is
+//done. The messages nacked fail with a NackedError and the mandatory returned with a ReturnedError. The channel is
+//closed after, to don't pass the confirm mode to the next holders
+func (pool *Pool) PublishConfirm(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	reusableChannel, err := pool.GetReusableChannelContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = pool.CloseReusableChannel(reusableChannel.ID)
+		_ = reusableChannel.Release()
+	}()
+
+	if err := reusableChannel.Confirm(false); err != nil {
+		return err
+	}
+
+	confirms, err := reusableChannel.NotifyPublish(make(chan amqp.Confirmation, 1))
+	if err != nil {
+		return err
+	}
+
+	returns, err := reusableChannel.NotifyReturn(make(chan amqp.Return, 1))
+	if err != nil {
+		return err
+	}
+
+	if err := reusableChannel.PublishContext(ctx, exchange, key, mandatory, immediate, msg); err != nil {
+		return err
+	}
+
+	select {
+	case confirmation, open := <-confirms:
+		if !open {
+			return &ChannelClosedError{ID: reusableChannel.ID, Err: amqp.ErrClosed}
+		}
+
+		select {
+		case returned := <-returns:
+			return &ReturnedError{Return: returned}
+		default:
+		}
+
+		if !confirmation.Ack {
+			return &NackedError{DeliveryTag: confirmation.DeliveryTag}
+		}
+
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
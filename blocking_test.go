@@ -0,0 +1,162 @@
+package amqppool_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/gmarcial/amqppool/amqppooltest"
+	"github.com/streadway/amqp"
+)
+
+func TestShouldFailFastThePublishWhenTheConnectionIsBlocked(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	reusableChannel, _ := pool.GetReusableChannel()
+	defer reusableChannel.Release()
+
+	broker.Block("low on memory")
+	eventually(t, pool.IsBlocked, "The pool was not blocked")
+
+	//Action
+	err := reusableChannel.Publish("", "orders", false, false, amqp.Publishing{})
+
+	//Assert
+	var blockedErr *amqppool.ConnectionBlockedError
+	if !errors.Is(err, amqppool.ErrConnectionBlocked) || !errors.As(err, &blockedErr) || blockedErr.Reason != "low on memory" {
+		t.Errorf("The error returned is different of expected: %v", err)
+	}
+
+	broker.Unblock()
+	eventually(t, func() bool { return !pool.IsBlocked() }, "The pool was not unblocked")
+}
+
+func TestShouldWaitTheConnectionBeUnblockedToPublish(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	reusableChannel, _ := pool.GetReusableChannel()
+	defer reusableChannel.Release()
+	_, _ = reusableChannel.QueueDeclare("orders", false, false, false, false, nil)
+
+	broker.Block("low on memory")
+	eventually(t, pool.IsBlocked, "The pool was not blocked")
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		broker.Unblock()
+	}()
+
+	//Action
+	err := reusableChannel.PublishContext(context.Background(), "", "orders", false, false, amqp.Publishing{})
+
+	//Assert
+	if err != nil {
+		t.Errorf("Occurred a error to publish after the connection be unblocked: %v", err.Error())
+	}
+
+	if broker.MessageCount("orders") != 1 {
+		t.Errorf("The message was not published")
+	}
+}
+
+func TestShouldGiveUpOfPublishWhenTheContextIsDoneWhileTheConnectionIsBlocked(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	broker.Block("low on disk")
+	eventually(t, pool.IsBlocked, "The pool was not blocked")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	//Action
+	err := pool.Publish(ctx, "", "orders", false, false, amqp.Publishing{})
+
+	//Assert
+	if !errors.Is(err, amqppool.ErrConnectionBlocked) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("The error returned is different of expected: %v", err)
+	}
+
+	broker.Unblock()
+}
+
+func TestShouldUnblockThePoolWhenReconnect(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	broker.Block("low on memory")
+	eventually(t, pool.IsBlocked, "The pool was not blocked")
+	broker.Unblock()
+	broker.Block("low on memory")
+
+	//Action
+	broker.KillConnections(amqp.ConnectionForced, "shutdown")
+
+	//Assert
+	eventually(t, func() bool { return pool.Generation() == 1 }, "The pool don't reconnected with the broker")
+	if pool.IsBlocked() {
+		t.Error("The pool still blocked with the new connection")
+	}
+
+	broker.Unblock()
+}
+
+func TestShouldFailThePublishConfirmedWhenTheBrokerNackOrReturnIt(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	declarer, _ := pool.GetReusableChannel()
+	_, _ = declarer.QueueDeclare("orders", false, false, false, false,
+		amqp.Table{"x-max-length": int64(1), "x-overflow": "reject-publish"})
+	_ = declarer.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	//Action
+	confirmedErr := pool.PublishConfirm(ctx, "", "orders", false, false, amqp.Publishing{Body: []byte("order 1")})
+	nackedErr := pool.PublishConfirm(ctx, "", "orders", false, false, amqp.Publishing{Body: []byte("order 2")})
+	returnedErr := pool.PublishConfirm(ctx, "", "payments", true, false, amqp.Publishing{Body: []byte("payment 1")})
+
+	//Assert
+	if confirmedErr != nil {
+		t.Errorf("Occurred a error to publish confirmed: %v", confirmedErr.Error())
+	}
+
+	var nacked *amqppool.NackedError
+	if !errors.Is(nackedErr, amqppool.ErrNacked) || !errors.As(nackedErr, &nacked) || nacked.DeliveryTag != 1 ||
+		!amqppool.IsRetryable(nackedErr) {
+		t.Errorf("The error of the message nacked is different of expected: %v", nackedErr)
+	}
+
+	if !errors.Is(returnedErr, amqppool.ErrReturned) {
+		t.Errorf("The error of the message returned is different of expected: %v", returnedErr)
+	}
+
+	if broker.MessageCount("orders") != 1 {
+		t.Errorf("The quantity of messages is different of expected: %v", broker.MessageCount("orders"))
+	}
+}
@@ -0,0 +1,91 @@
+package amqppool
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"mime"
+
+	"github.com/streadway/amqp"
+)
+
+//Codec encode and decode the body of the messages in a content type, the codecs of protobuf and msgpack are in the
+//packages amqppoolprotobuf and amqppoolmsgpack
+type Codec interface {
+	ContentType() string                            //the content type of the bodies encoded, e.g. application/json
+	Marshal(value interface{}) ([]byte, error)      //encode a value to a body
+	Unmarshal(body []byte, value interface{}) error //decode a body to the value pointed
+}
+
+//The codecs of the encodings of the standard library
+var (
+	JSON Codec = jsonCodec{}
+	Gob  Codec = gobCodec{}
+)
+
+//DeliveryMiddleware transform a delivery before it is decoded, e.g. to decompress or decrypt its body, the
+//deliveries which it fails are rejected how the undecodable
+type DeliveryMiddleware func(delivery amqp.Delivery) (amqp.Delivery, error)
+
+//jsonCodec encode and decode the bodies in JSON
+type jsonCodec struct{}
+
+//ContentType get the content type application/json
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+//Marshal encode a value in JSON
+func (jsonCodec) Marshal(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+//Unmarshal decode a body in JSON
+func (jsonCodec) Unmarshal(body []byte, value interface{}) error {
+	return json.Unmarshal(body, value)
+}
+
+//gobCodec encode and decode the bodies with encoding/gob
+type gobCodec struct{}
+
+//ContentType get the content type application/x-gob
+func (gobCodec) ContentType() string {
+	return "application/x-gob"
+}
+
+//Marshal encode a value with encoding/gob
+func (gobCodec) Marshal(value interface{}) ([]byte, error) {
+	var body bytes.Buffer
+	if err := gob.NewEncoder(&body).Encode(value); err != nil {
+		return nil, err
+	}
+
+	return body.Bytes(), nil
+}
+
+//Unmarshal decode a body with encoding/gob
+func (gobCodec) Unmarshal(body []byte, value interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(body)).Decode(value)
+}
+
+//codecFor get the codec of the content type of a message, ignoring its parameters, the first codec when the message
+//don't have a content type
+func codecFor(codecs []Codec, contentType string) (Codec, error) {
+	if contentType == "" && len(codecs) > 0 {
+		return codecs[0], nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the content type %q: %w", contentType, err)
+	}
+
+	for _, codec := range codecs {
+		if codec.ContentType() == mediaType {
+			return codec, nil
+		}
+	}
+
+	return nil, fmt.Errorf("there is no codec of the content type %q", contentType)
+}
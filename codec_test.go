@@ -0,0 +1,32 @@
+package amqppool_test
+
+import (
+	"testing"
+
+	"github.com/gmarcial/amqppool"
+)
+
+func TestShouldEncodeAndDecodeTheBodiesInTheCodecsOfTheStandardLibrary(t *testing.T) {
+	//Arrange
+	type order struct {
+		ID    int
+		Items []string
+	}
+	codecs := map[string]amqppool.Codec{"application/json": amqppool.JSON, "application/x-gob": amqppool.Gob}
+
+	for contentType, codec := range codecs {
+		//Action
+		body, err := codec.Marshal(order{ID: 1, Items: []string{"book"}})
+		var decoded order
+		decodeErr := codec.Unmarshal(body, &decoded)
+
+		//Assert
+		if codec.ContentType() != contentType {
+			t.Errorf("The content type of the codec is different of expected %v: %v", contentType, codec.ContentType())
+		}
+
+		if err != nil || decodeErr != nil || decoded.ID != 1 || len(decoded.Items) != 1 || decoded.Items[0] != "book" {
+			t.Errorf("The message decoded in %v is inconsistent: %+v, %v, %v", contentType, decoded, err, decodeErr)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package amqppool
+
+import (
+	"reflect"
+
+	"github.com/streadway/amqp"
+)
+
+//Connection represents the connection amqp on which the pool depends, by default a *amqp.Connection
+type Connection interface {
+	Channel() (Channel, error)
+	NotifyClose(receiver chan *amqp.Error) chan *amqp.Error
+	NotifyBlocked(receiver chan amqp.Blocking) chan amqp.Blocking
+	Close() error
+}
+
+//Channel represents the channel amqp wrapped by a reusable channel, implemented by *amqp.Channel
+type Channel interface {
+	Close() error
+	Ack(tag uint64, multiple bool) error
+	Reject(tag uint64, requeue bool) error
+	Nack(tag uint64, multiple bool, requeue bool) error
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error
+	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
+	Cancel(consumer string, noWait bool) error
+	Confirm(noWait bool) error
+	ExchangeBind(destination, key, source string, noWait bool, args amqp.Table) error
+	ExchangeDeclarePassive(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
+	ExchangeDelete(name string, ifUnused, noWait bool) error
+	ExchangeUnbind(destination, key, source string, noWait bool, args amqp.Table) error
+	Flow(active bool) error
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	Recover(requeue bool) error
+	QueueUnbind(name, key, exchange string, args amqp.Table) error
+	Tx() error
+	TxCommit() error
+	TxRollback() error
+	Get(queue string, autoAck bool) (msg amqp.Delivery, ok bool, err error)
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	QueueInspect(name string) (amqp.Queue, error)
+	NotifyClose(c chan *amqp.Error) chan *amqp.Error
+	NotifyCancel(c chan string) chan string
+	NotifyConfirm(ack, nack chan uint64) (chan uint64, chan uint64)
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	NotifyFlow(c chan bool) chan bool
+	NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation
+	NotifyReturn(c chan amqp.Return) chan amqp.Return
+	QueueDeclarePassive(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueDelete(name string, ifUnused, ifEmpty, noWait bool) (int, error)
+}
+
+//NumberedChannel represents a Channel which know its number in the connection, to map the reusable channels to
+//the channels amqp when the Channel is not a *amqp.Channel
+type NumberedChannel interface {
+	Number() uint16
+}
+
+//channelNumber get the number of a channel amqp in its connection, 0 when it is unknown
+func channelNumber(channel Channel) uint16 {
+	switch channel := channel.(type) {
+	case NumberedChannel:
+		return channel.Number()
+	case *amqp.Channel:
+		//the number of *amqp.Channel is not exported, pinned by a test to the version of streadway/amqp required
+		if id := reflect.ValueOf(channel).Elem().FieldByName("id"); id.IsValid() && id.Kind() == reflect.Uint16 {
+			return uint16(id.Uint())
+		}
+	}
+
+	return 0
+}
+
+//Dialer establish the connection with the broker amqp
+type Dialer func(connectionString string) (Connection, error)
+
+//amqpConnection adapt a *amqp.Connection to the Connection of the pool
+type amqpConnection struct {
+	connection *amqp.Connection //the connection amqp
+}
+
+var _ Channel = (*amqp.Channel)(nil)
+
+//Channel open a new channel amqp
+func (amqpConnection *amqpConnection) Channel() (Channel, error) {
+	channel, err := amqpConnection.connection.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	return channel, nil
+}
+
+//NotifyClose register a listener for when the connection amqp is closed
+func (amqpConnection *amqpConnection) NotifyClose(receiver chan *amqp.Error) chan *amqp.Error {
+	return amqpConnection.connection.NotifyClose(receiver)
+}
+
+//NotifyBlocked register a listener for when the broker block or unblock the publishes of the connection amqp
+func (amqpConnection *amqpConnection) NotifyBlocked(receiver chan amqp.Blocking) chan amqp.Blocking {
+	return amqpConnection.connection.NotifyBlocked(receiver)
+}
+
+//Close close the connection amqp
+func (amqpConnection *amqpConnection) Close() error {
+	return amqpConnection.connection.Close()
+}
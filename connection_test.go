@@ -0,0 +1,26 @@
+package amqppool
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/streadway/amqp"
+)
+
+func TestShouldFindTheNumberOfTheChannelsOfStreadway(t *testing.T) {
+	//Arrange
+	channel := &amqp.Channel{}
+	id := reflect.ValueOf(channel).Elem().FieldByName("id")
+
+	//Action
+	number := channelNumber(channel)
+
+	//Assert
+	if !id.IsValid() || id.Kind() != reflect.Uint16 {
+		t.Fatalf("The field id of *amqp.Channel was not found, the number of the channels is unknown: %v", id.Kind())
+	}
+
+	if number != 0 {
+		t.Errorf("The number of the channel is different of expected: %v", number)
+	}
+}
@@ -0,0 +1,123 @@
+package amqppool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+//deliveryHandler handle a message consumed in a reusable channel, acknowledging it
+type deliveryHandler func(ctx context.Context, channel *ReusableChannel, delivery amqp.Delivery)
+
+//consume consume the messages of a queue until the context is done, handling until concurrency messages at same time,
+//consuming again in other reusable channel when the channel or the connection is closed. It returns nil when the
+//context is done, after the messages in progress are handled, or the error which can't be retried. The messages are
+//handled with the values of the context, but without its cancel, so the messages in progress are finished when it
+//is done
+func (pool *Pool) consume(ctx context.Context, queue string, concurrency int, handle deliveryHandler) error {
+	for {
+		err := pool.consumeChannel(ctx, queue, concurrency, handle)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if !IsRetryable(err) {
+			return err
+		}
+
+		pool.logger.Warn("stopped to consume the queue, consuming again", "queue", queue, "error", err.Error())
+		select {
+		case <-time.After(pool.reconnectDelay):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+//consumeChannel consume the messages of a queue in a reusable channel until the context is done or the channel is
+//closed
+func (pool *Pool) consumeChannel(ctx context.Context, queue string, concurrency int, handle deliveryHandler) error {
+	reusableChannel, err := pool.GetReusableChannelContext(ctx)
+	if err != nil {
+		return err
+	}
+	//the channel is closed when released, because its listeners and its prefetch would carry over to the next holder
+	defer func() {
+		_ = pool.CloseReusableChannel(reusableChannel.ID)
+		_ = reusableChannel.Release()
+	}()
+
+	closes, _ := reusableChannel.NotifyClose(make(chan *amqp.Error, 1))
+	cancels, _ := reusableChannel.NotifyCancel(make(chan string, 1))
+	if err := reusableChannel.Qos(concurrency, 0, false); err != nil {
+		return err
+	}
+
+	consumerTag := fmt.Sprintf("amqppool-%v-%v", queue, reusableChannel.ID)
+	deliveries, err := reusableChannel.Consume(queue, consumerTag, false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	var inProgress sync.WaitGroup
+	defer inProgress.Wait()
+
+	for {
+		select {
+		case delivery, open := <-deliveries:
+			if !open {
+				return consumeStopped(ctx, reusableChannel, closes, cancels)
+			}
+
+			inProgress.Add(1)
+			go func() {
+				defer inProgress.Done()
+				handle(detachedContext{ctx}, reusableChannel, delivery)
+			}()
+		case <-ctx.Done():
+			//the messages delivered after the cancel are requeued, to don't keep them unacknowledged in the channel
+			_ = reusableChannel.Cancel(consumerTag, false)
+			for delivery := range deliveries {
+				_ = reusableChannel.Nack(delivery.DeliveryTag, false, true)
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+//consumeStopped get why the consumer of a reusable channel stopped
+func consumeStopped(ctx context.Context, reusableChannel *ReusableChannel, closes chan *amqp.Error, cancels chan string) error {
+	select {
+	case closeErr := <-closes:
+		return &ChannelClosedError{ID: reusableChannel.ID, Err: closeErr}
+	case <-cancels:
+		return &ChannelClosedError{ID: reusableChannel.ID, Err: errors.New("the consumer was cancelled by the broker")}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//detachedContext keep the values of a context without its cancel and deadline, to finish the work in progress after
+//the context is done
+type detachedContext struct {
+	context.Context
+}
+
+//Deadline the context detached don't have deadline
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+//Done the context detached is never done
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+//Err the context detached is never done
+func (detachedContext) Err() error {
+	return nil
+}
@@ -1,8 +1,33 @@
 package amqppool
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
 var (
 	ErrAllChannelsInUse = &AllChannelsInUseError{message: "failed in try get a reusable channel, all are in use"}
 	ErrUseReleaseChannel = &UseReleaseChannelError{message: "Tried to use a reusable channel that was already released"}
+	ErrPoolClosed        = &PoolClosedError{message: "the pool was closed"}
+	ErrAlreadyReleased   = &AlreadyReleasedError{message: "Tried to release a reusable channel that was already released"}
+	ErrConnectionBlocked = &ConnectionBlockedError{}
+	ErrRPCClientClosed   = &RPCClientClosedError{message: "the rpc client was closed"}
+	ErrChannelNotFound   = &ChannelNotFoundError{message: "the reusable channel was not found in the pool"}
+)
+
+//Sentinels matched by errors.Is with any error of its type
+var (
+	ErrConnectionClosed   = &ConnectionClosedError{}
+	ErrChannelClosed      = &ChannelClosedError{}
+	ErrAcquireTimeout     = &AcquireTimeoutError{}
+	ErrNacked             = &NackedError{}
+	ErrReturned           = &ReturnedError{}
+	ErrReconnectExhausted = &ReconnectExhaustedError{}
+	ErrRPC                = &RPCError{}
 )
 
 //AllChannelsInUseError an error of when is tried to get a reusable channel, but was hit the maximum quantity of pool.
@@ -24,3 +49,378 @@ type UseReleaseChannelError struct {
 func (err *UseReleaseChannelError) Error() string {
 	return err.message
 }
+
+//PoolClosedError an error of when is tried to get or release a reusable channel, but the pool was closed.
+type PoolClosedError struct {
+	message string
+}
+
+//Error implementing the error interface
+func (err *PoolClosedError) Error() string {
+	return err.message
+}
+
+//AlreadyReleasedError an error of when is tried to release a reusable channel that was already released.
+type AlreadyReleasedError struct {
+	message string
+}
+
+//Error implementing the error interface
+func (err *AlreadyReleasedError) Error() string {
+	return err.message
+}
+
+//RPCClientClosedError an error of when is tried to call by a RPCClient, or a call was waiting reply, but it was closed.
+type RPCClientClosedError struct {
+	message string
+}
+
+//Error implementing the error interface
+func (err *RPCClientClosedError) Error() string {
+	return err.message
+}
+
+//ChannelNotFoundError an error of when is tried to close a reusable channel, but it is not in the pool.
+type ChannelNotFoundError struct {
+	message string
+}
+
+//Error implementing the error interface
+func (err *ChannelNotFoundError) Error() string {
+	return err.message
+}
+
+//ConnectionBlockedError an error of when is tried to publish, but the broker blocked the publishes of the connection.
+//All instances are matched by errors.Is with ErrConnectionBlocked
+type ConnectionBlockedError struct {
+	Reason string //the reason of the block informed by the broker
+	Err    error  //the error of the context when gave up of wait the connection be unblocked, if any
+}
+
+//Error implementing the error interface
+func (err *ConnectionBlockedError) Error() string {
+	message := "failed in try publish, the connection is blocked by the broker"
+	if err.Reason != "" {
+		message += ": " + err.Reason
+	}
+	if err.Err != nil {
+		message += ": " + err.Err.Error()
+	}
+
+	return message
+}
+
+//Is match any ConnectionBlockedError, to use errors.Is with ErrConnectionBlocked
+func (err *ConnectionBlockedError) Is(target error) bool {
+	_, ok := target.(*ConnectionBlockedError)
+	return ok
+}
+
+//Unwrap get the error of the context
+func (err *ConnectionBlockedError) Unwrap() error {
+	return err.Err
+}
+
+//ConnectionClosedError an error of when the connection with the broker was closed, by a connection exception or
+//the network. All instances are matched by errors.Is with ErrConnectionClosed
+type ConnectionClosedError struct {
+	Err error //the cause of the close, usually a *amqp.Error
+}
+
+//Error implementing the error interface
+func (err *ConnectionClosedError) Error() string {
+	return withCause("the connection with the broker was closed", err.Err)
+}
+
+//Is match any ConnectionClosedError, to use errors.Is with ErrConnectionClosed
+func (err *ConnectionClosedError) Is(target error) bool {
+	_, ok := target.(*ConnectionClosedError)
+	return ok
+}
+
+//Unwrap get the cause of the close
+func (err *ConnectionClosedError) Unwrap() error {
+	return err.Err
+}
+
+//ChannelClosedError an error of when the channel of a reusable channel was closed, by a channel exception or with
+//its connection. All instances are matched by errors.Is with ErrChannelClosed
+type ChannelClosedError struct {
+	ID  int   //the identification of the reusable channel
+	Err error //the cause of the close, usually a *amqp.Error
+}
+
+//Error implementing the error interface
+func (err *ChannelClosedError) Error() string {
+	return withCause(fmt.Sprintf("the channel of the reusable channel %v was closed", err.ID), err.Err)
+}
+
+//Is match any ChannelClosedError, to use errors.Is with ErrChannelClosed
+func (err *ChannelClosedError) Is(target error) bool {
+	_, ok := target.(*ChannelClosedError)
+	return ok
+}
+
+//Unwrap get the cause of the close
+func (err *ChannelClosedError) Unwrap() error {
+	return err.Err
+}
+
+//AcquireTimeoutError an error of when gave up of wait a reusable channel be released. All instances are matched by
+//errors.Is with ErrAcquireTimeout
+type AcquireTimeoutError struct {
+	Waited time.Duration //how long was waited
+	Err    error         //the error of the context
+}
+
+//Error implementing the error interface
+func (err *AcquireTimeoutError) Error() string {
+	return withCause(fmt.Sprintf("failed in try get a reusable channel, all are in use after wait %v", err.Waited), err.Err)
+}
+
+//Is match any AcquireTimeoutError, to use errors.Is with ErrAcquireTimeout
+func (err *AcquireTimeoutError) Is(target error) bool {
+	_, ok := target.(*AcquireTimeoutError)
+	return ok
+}
+
+//Unwrap get the error of the context
+func (err *AcquireTimeoutError) Unwrap() error {
+	return err.Err
+}
+
+//NackedError an error of when the broker nacked a message published in confirm mode. All instances are matched by
+//errors.Is with ErrNacked
+type NackedError struct {
+	DeliveryTag uint64 //the sequence of the publishing nacked
+}
+
+//Error implementing the error interface
+func (err *NackedError) Error() string {
+	return fmt.Sprintf("the message published with delivery tag %v was nacked by the broker", err.DeliveryTag)
+}
+
+//Is match any NackedError, to use errors.Is with ErrNacked
+func (err *NackedError) Is(target error) bool {
+	_, ok := target.(*NackedError)
+	return ok
+}
+
+//ReturnedError an error of when the broker returned a message mandatory or immediate that could not be routed. All
+//instances are matched by errors.Is with ErrReturned
+type ReturnedError struct {
+	Return amqp.Return //the message returned
+}
+
+//Error implementing the error interface
+func (err *ReturnedError) Error() string {
+	return fmt.Sprintf("the message published to %q with key %q was returned by the broker: %v %v",
+		err.Return.Exchange, err.Return.RoutingKey, err.Return.ReplyCode, err.Return.ReplyText)
+}
+
+//Is match any ReturnedError, to use errors.Is with ErrReturned
+func (err *ReturnedError) Is(target error) bool {
+	_, ok := target.(*ReturnedError)
+	return ok
+}
+
+//ReconnectExhaustedError an error of when the pool gave up of reconnect with the broker after the maximum of
+//attempts. All instances are matched by errors.Is with ErrReconnectExhausted
+type ReconnectExhaustedError struct {
+	Attempts int   //the quantity of attempts
+	Err      error //the error of the last attempt
+}
+
+//Error implementing the error interface
+func (err *ReconnectExhaustedError) Error() string {
+	return withCause(fmt.Sprintf("gave up of reconnect with the broker after %v attempts", err.Attempts), err.Err)
+}
+
+//Is match any ReconnectExhaustedError, to use errors.Is with ErrReconnectExhausted
+func (err *ReconnectExhaustedError) Is(target error) bool {
+	_, ok := target.(*ReconnectExhaustedError)
+	return ok
+}
+
+//Unwrap get the error of the last attempt
+func (err *ReconnectExhaustedError) Unwrap() error {
+	return err.Err
+}
+
+//RPCError an error of a handler of a RPCServer, replied to the RPCClient in the headers of the reply. All instances
+//are matched by errors.Is with ErrRPC
+type RPCError struct {
+	Code    string //identify the kind of error to the client, RPCErrorInternal when the handler returned other error
+	Message string //the message of the error
+}
+
+//Error implementing the error interface
+func (err *RPCError) Error() string {
+	return fmt.Sprintf("the rpc failed with %v: %v", err.Code, err.Message)
+}
+
+//Is match any RPCError, to use errors.Is with ErrRPC
+func (err *RPCError) Is(target error) bool {
+	_, ok := target.(*RPCError)
+	return ok
+}
+
+//withCause append the cause to the message of an error, if any
+func withCause(message string, cause error) string {
+	if cause == nil {
+		return message
+	}
+
+	return message + ": " + cause.Error()
+}
+
+//ReplyCode get the reply code of the broker of an error, from the *amqp.Error or the message returned wrapped, 0 when
+//the error don't have one
+func ReplyCode(err error) int {
+	var amqpErr *amqp.Error
+	if errors.As(err, &amqpErr) {
+		return amqpErr.Code
+	}
+
+	var returned *ReturnedError
+	if errors.As(err, &returned) {
+		return int(returned.Return.ReplyCode)
+	}
+
+	return 0
+}
+
+//IsNotFound indicates if the error is the exception NOT_FOUND of the broker, e.g. of an exchange or queue not declared
+func IsNotFound(err error) bool {
+	return ReplyCode(err) == amqp.NotFound
+}
+
+//IsPreconditionFailed indicates if the error is the exception PRECONDITION_FAILED of the broker, e.g. of a queue
+//redeclared with other arguments
+func IsPreconditionFailed(err error) bool {
+	return ReplyCode(err) == amqp.PreconditionFailed
+}
+
+//IsAccessRefused indicates if the error is the exception ACCESS_REFUSED of the broker, e.g. of a user without
+//permission or an exclusive queue of other connection
+func IsAccessRefused(err error) bool {
+	return ReplyCode(err) == amqp.AccessRefused
+}
+
+//IsResourceLocked indicates if the error is the exception RESOURCE_LOCKED of the broker, e.g. of an exclusive consumer
+func IsResourceLocked(err error) bool {
+	return ReplyCode(err) == amqp.ResourceLocked
+}
+
+//IsConnectionForced indicates if the error is the exception CONNECTION_FORCED of the broker, e.g. when RabbitMQ is
+//stopped or the connection is closed by the management
+func IsConnectionForced(err error) bool {
+	return ReplyCode(err) == amqp.ConnectionForced
+}
+
+//Scope represents what an error closed, indicating if the channel or the whole connection can't be used anymore
+type Scope int
+
+const (
+	ScopeNone       Scope = iota //the error don't closed the channel
+	ScopeChannel                 //the error closed the channel, the connection still open
+	ScopeConnection              //the error closed the connection with all its channels
+)
+
+//String get the name of the scope
+func (scope Scope) String() string {
+	switch scope {
+	case ScopeChannel:
+		return "channel"
+	case ScopeConnection:
+		return "connection"
+	default:
+		return "none"
+	}
+}
+
+//channelExceptions the reply codes of the exceptions which close only the channel
+var channelExceptions = map[int]bool{
+	amqp.ContentTooLarge:    true,
+	amqp.NoConsumers:        true,
+	amqp.AccessRefused:      true,
+	amqp.NotFound:           true,
+	amqp.ResourceLocked:     true,
+	amqp.PreconditionFailed: true,
+}
+
+//ScopeOf get what the error closed, according to the reply code of the exception of the broker. The error of the
+//client of use a channel not open, amqp.ErrClosed, is of scope of channel
+func ScopeOf(err error) Scope {
+	var amqpErr *amqp.Error
+	switch {
+	case err == nil:
+		return ScopeNone
+	case errors.As(err, &amqpErr) && amqpErr.Code == amqp.ChannelError && !amqpErr.Server:
+		return ScopeChannel
+	case amqpErr != nil && channelExceptions[amqpErr.Code]:
+		return ScopeChannel
+	case amqpErr != nil && (amqpErr.Code == amqp.ConnectionForced || amqpErr.Code == amqp.InvalidPath ||
+		amqpErr.Code >= amqp.FrameError):
+		return ScopeConnection
+	case errors.Is(err, ErrConnectionClosed):
+		return ScopeConnection
+	case errors.Is(err, ErrChannelClosed):
+		return ScopeChannel
+	}
+
+	return ScopeNone
+}
+
+//retryableCodes the reply codes of the conditions which can pass when the operation is retried, in other channel or
+//after the reconnection
+var retryableCodes = map[int]bool{
+	amqp.ConnectionForced: true,
+	amqp.FrameError:       true,
+	amqp.ChannelError:     true,
+	amqp.ResourceLocked:   true,
+	amqp.ResourceError:    true,
+	amqp.InternalError:    true,
+}
+
+//IsRetryable indicates if the operation failed with the error can succeed when retried, like when all channels are
+//in use, the connection was closed or blocked, or the message was nacked. The errors of the usage of the pool, the
+//messages returned and the exceptions caused by the arguments, like NOT_FOUND or ACCESS_REFUSED, are not retryable
+func IsRetryable(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ErrPoolClosed), errors.Is(err, ErrReconnectExhausted), errors.Is(err, ErrReturned),
+		errors.Is(err, ErrRPCClientClosed), errors.Is(err, ErrChannelNotFound):
+		return false
+	case errors.Is(err, ErrAllChannelsInUse), errors.Is(err, ErrAcquireTimeout), errors.Is(err, ErrConnectionBlocked),
+		errors.Is(err, ErrNacked):
+		return true
+	}
+
+	if code := ReplyCode(err); code != 0 {
+		return retryableCodes[code]
+	}
+
+	return errors.Is(err, ErrConnectionClosed) || errors.Is(err, ErrChannelClosed)
+}
+
+//InvalidTopologyError an error of when a topology have declarations that can't be applied in the broker.
+type InvalidTopologyError struct {
+	Problems []string //each problem found in the topology
+}
+
+//Error implementing the error interface
+func (err *InvalidTopologyError) Error() string {
+	return "invalid topology: " + strings.Join(err.Problems, "; ")
+}
+
+//InvalidArgumentsError an error of when the arguments of a declaration are incompatible between them or with its properties.
+type InvalidArgumentsError struct {
+	Problems []string //each problem found in the arguments
+}
+
+//Error implementing the error interface
+func (err *InvalidArgumentsError) Error() string {
+	return "invalid arguments: " + strings.Join(err.Problems, "; ")
+}
@@ -0,0 +1,108 @@
+package amqppool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/streadway/amqp"
+)
+
+func TestShouldClassifyTheErrorsHowRetryableOrNot(t *testing.T) {
+	//Arrange
+	cases := []struct {
+		err       error
+		retryable bool
+		code      int
+	}{
+		{ErrAllChannelsInUse, true, 0},
+		{&AcquireTimeoutError{Err: context.DeadlineExceeded}, true, 0},
+		{&ConnectionBlockedError{Reason: "low on memory"}, true, 0},
+		{&NackedError{DeliveryTag: 1}, true, 0},
+		{fmt.Errorf("failed to close: %w", ErrChannelNotFound), false, 0},
+		{&ConnectionClosedError{Err: &amqp.Error{Code: amqp.ConnectionForced}}, true, amqp.ConnectionForced},
+		{&ChannelClosedError{ID: 1, Err: amqp.ErrClosed}, true, amqp.ChannelError},
+		{fmt.Errorf("failed to declare: %w", &amqp.Error{Code: amqp.NotFound}), false, amqp.NotFound},
+		{&amqp.Error{Code: amqp.AccessRefused}, false, amqp.AccessRefused},
+		{&ReturnedError{Return: amqp.Return{ReplyCode: amqp.NoRoute}}, false, amqp.NoRoute},
+		{&ReconnectExhaustedError{Attempts: 3, Err: errors.New("connection refused")}, false, 0},
+		{ErrPoolClosed, false, 0},
+		{ErrAlreadyReleased, false, 0},
+	}
+
+	for _, c := range cases {
+		//Action
+		retryable := IsRetryable(c.err)
+		code := ReplyCode(c.err)
+
+		//Assert
+		if retryable != c.retryable || code != c.code {
+			t.Errorf("The classification of %v is inconsistent: retryable %v and code %v", c.err, retryable, code)
+		}
+	}
+}
+
+func TestShouldMatchTheErrorsByTheSentinelOfItsType(t *testing.T) {
+	//Arrange
+	err := fmt.Errorf("failed in publish: %w", &ChannelClosedError{ID: 2, Err: &amqp.Error{Code: amqp.PreconditionFailed}})
+
+	//Action
+	var amqpErr *amqp.Error
+	unwrapped := errors.As(err, &amqpErr)
+
+	//Assert
+	if !errors.Is(err, ErrChannelClosed) || errors.Is(err, ErrConnectionClosed) {
+		t.Errorf("The error don't was matched only by its sentinel: %v", err)
+	}
+
+	if !unwrapped || amqpErr.Code != amqp.PreconditionFailed {
+		t.Errorf("The cause was not wrapped: %v", err)
+	}
+}
+
+func TestShouldClassifyTheScopeClosedByTheExceptions(t *testing.T) {
+	//Arrange
+	cases := []struct {
+		err   error
+		scope Scope
+	}{
+		{nil, ScopeNone},
+		{ErrAllChannelsInUse, ScopeNone},
+		{&ReturnedError{Return: amqp.Return{ReplyCode: amqp.NoRoute}}, ScopeNone},
+		{&amqp.Error{Code: amqp.NotFound, Server: true}, ScopeChannel},
+		{fmt.Errorf("failed to declare: %w", &amqp.Error{Code: amqp.PreconditionFailed, Server: true}), ScopeChannel},
+		{amqp.ErrClosed, ScopeChannel},
+		{&amqp.Error{Code: amqp.ConnectionForced, Server: true}, ScopeConnection},
+		{&amqp.Error{Code: amqp.ChannelError, Server: true}, ScopeConnection},
+		{&ConnectionClosedError{}, ScopeConnection},
+	}
+
+	for _, c := range cases {
+		//Action
+		scope := ScopeOf(c.err)
+
+		//Assert
+		if scope != c.scope {
+			t.Errorf("The scope of %v is inconsistent: Expected %v and found %v", c.err, c.scope, scope)
+		}
+	}
+}
+
+func TestShouldIdentifyTheExceptionsByItsReplyCode(t *testing.T) {
+	//Arrange
+	notFound := fmt.Errorf("failed to verify: %w", &amqp.Error{Code: amqp.NotFound})
+
+	//Action
+	identified := IsNotFound(notFound)
+
+	//Assert
+	if !identified || IsPreconditionFailed(notFound) || IsAccessRefused(notFound) || IsResourceLocked(notFound) ||
+		IsConnectionForced(notFound) {
+		t.Errorf("The exception was identified wrongly: %v", notFound)
+	}
+
+	if !IsAccessRefused(&amqp.Error{Code: amqp.AccessRefused}) || !IsConnectionForced(&amqp.Error{Code: amqp.ConnectionForced}) {
+		t.Error("The exceptions were not identified by its reply code")
+	}
+}
@@ -0,0 +1,165 @@
+package amqppool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+//Event represents an event of the lifecycle of a pool, one of the types of events declared below
+type Event interface {
+	event()
+}
+
+//ConnectionLost the connection with the broker was closed by an error
+type ConnectionLost struct {
+	Err        *amqp.Error //the error that closed the connection
+	Generation int         //the generation of the connection lost
+}
+
+//ReconnectAttempt an attempt to reconnect with the broker failed
+type ReconnectAttempt struct {
+	Attempt int   //the number of the attempt, from 1
+	Err     error //the error of the attempt
+}
+
+//Reconnected the pool reconnected with the broker
+type Reconnected struct {
+	Attempts   int //the quantity of attempts until reconnect
+	Generation int //the generation of the new connection
+}
+
+//ReconnectFailed the pool gave up of reconnect with the broker after the maximum of attempts
+type ReconnectFailed struct {
+	Attempts int   //the quantity of attempts
+	Err      error //the ReconnectExhaustedError returned when is tried to get a reusable channel
+}
+
+//ChannelCreated a channel amqp was opened to a reusable channel, new or renewed
+type ChannelCreated struct {
+	ID         int //the identification of the reusable channel
+	Generation int //the generation of the connection of the channel
+}
+
+//ChannelDiscarded the channel amqp of a reusable channel was discarded because was closed by the broker or its
+//connection was lost
+type ChannelDiscarded struct {
+	ID  int         //the identification of the reusable channel
+	Err *amqp.Error //the error that closed the channel or its connection, nil when it was closed gracefully
+}
+
+//AcquireTimeout a get of a reusable channel gave up of wait one be released
+type AcquireTimeout struct {
+	Waited time.Duration //the time waited
+	Err    error         //the error of the context
+}
+
+//ConnectionBlocked the broker blocked the publishes of the connection, e.g. by a resource alarm
+type ConnectionBlocked struct {
+	Reason string //the reason of the block informed by the broker
+}
+
+//ConnectionUnblocked the broker unblocked the publishes of the connection
+type ConnectionUnblocked struct{}
+
+func (ConnectionLost) event()      {}
+func (ReconnectAttempt) event()    {}
+func (Reconnected) event()         {}
+func (ReconnectFailed) event()     {}
+func (ChannelCreated) event()      {}
+func (ChannelDiscarded) event()    {}
+func (AcquireTimeout) event()      {}
+func (ConnectionBlocked) event()   {}
+func (ConnectionUnblocked) event() {}
+
+//Hook is called synchronously with the events of a pool, it must be fast and don't block
+type Hook func(event Event)
+
+//WithHook add a hook called with the events of the pool
+func WithHook(hook Hook) Option {
+	return func(pool *Pool) {
+		pool.events.hooks = append(pool.events.hooks, hook)
+	}
+}
+
+//events dispatch the events of a pool to the hooks and subscribers
+type events struct {
+	hooks       []Hook                  //called synchronously, configured in the creation of the pool
+	mutex       sync.Mutex              //guard the pending events and the subscribers
+	pending     []Event                 //events queued with the lock of the pool held, dispatched after release it
+	subscribers map[chan Event]struct{} //go channels of the subscribers
+	closed      bool                    //indicates when the subscribers were closed with the pool
+}
+
+//Subscribe subscribe the events of the pool in a buffered go channel, the events are dropped while the buffer is
+//full. The go channel is closed by unsubscribe or when the pool is closed
+func (pool *Pool) Subscribe(buffer int) (subscription <-chan Event, unsubscribe func()) {
+	receiver := make(chan Event, buffer)
+
+	pool.events.mutex.Lock()
+	defer pool.events.mutex.Unlock()
+
+	if pool.events.closed {
+		close(receiver)
+		return receiver, func() {}
+	}
+
+	if pool.events.subscribers == nil {
+		pool.events.subscribers = make(map[chan Event]struct{})
+	}
+	pool.events.subscribers[receiver] = struct{}{}
+
+	return receiver, func() {
+		pool.events.mutex.Lock()
+		defer pool.events.mutex.Unlock()
+
+		if _, exist := pool.events.subscribers[receiver]; exist {
+			delete(pool.events.subscribers, receiver)
+			close(receiver)
+		}
+	}
+}
+
+//queue queue an event to be dispatched after release the lock of the pool
+func (events *events) queue(event Event) {
+	events.mutex.Lock()
+	defer events.mutex.Unlock()
+
+	events.pending = append(events.pending, event)
+}
+
+//emit dispatch the events queued and the events informed, the lock of the pool must not be held
+func (events *events) emit(emitted ...Event) {
+	events.mutex.Lock()
+	pending := append(events.pending, emitted...)
+	events.pending = nil
+
+	for _, event := range pending {
+		for subscriber := range events.subscribers {
+			select {
+			case subscriber <- event:
+			default:
+			}
+		}
+	}
+	events.mutex.Unlock()
+
+	for _, event := range pending {
+		for _, hook := range events.hooks {
+			hook(event)
+		}
+	}
+}
+
+//close close the go channels of the subscribers
+func (events *events) close() {
+	events.mutex.Lock()
+	defer events.mutex.Unlock()
+
+	for subscriber := range events.subscribers {
+		close(subscriber)
+	}
+	events.subscribers = nil
+	events.closed = true
+}
@@ -0,0 +1,161 @@
+package amqppool_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/gmarcial/amqppool/amqppooltest"
+	"github.com/streadway/amqp"
+)
+
+//recorder record the events received by a hook
+type recorder struct {
+	mutex  sync.Mutex
+	events []amqppool.Event
+}
+
+//hook record an event
+func (recorder *recorder) hook(event amqppool.Event) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.events = append(recorder.events, event)
+}
+
+//recorded get the events recorded
+func (recorder *recorder) recorded() []amqppool.Event {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	return append([]amqppool.Event(nil), recorder.events...)
+}
+
+//next wait the next event of a subscription or fail the test
+func next(t *testing.T, subscription <-chan amqppool.Event) amqppool.Event {
+	select {
+	case event := <-subscription:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("Don't was received an event")
+		return nil
+	}
+}
+
+func TestShouldCallTheHooksWithTheEventsOfTheReconnection(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+	recorder := &recorder{}
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial),
+		amqppool.WithReconnectPolicy(0, time.Millisecond), amqppool.WithHook(recorder.hook))
+	defer pool.Close()
+
+	broker.RefuseConnections(nil)
+	broker.KillConnections(amqp.ConnectionForced, "shutdown")
+	eventually(t, func() bool { return len(recorder.recorded()) >= 3 }, "The attempt to reconnect was not notified")
+	broker.AcceptConnections()
+	eventually(t, func() bool { return pool.Generation() == 1 }, "The pool don't reconnected with the broker")
+
+	//Action
+	reusableChannel, _ := pool.GetReusableChannel()
+	defer reusableChannel.Release()
+
+	//Assert
+	events := recorder.recorded()
+	if !reflect.DeepEqual(events[0], amqppool.ChannelCreated{ID: 1}) {
+		t.Errorf("The first event is different of expected: %#v", events[0])
+	}
+
+	if lost, ok := events[1].(amqppool.ConnectionLost); !ok || lost.Err.Code != amqp.ConnectionForced || lost.Generation != 0 {
+		t.Errorf("The second event is different of expected: %#v", events[1])
+	}
+
+	if attempt, ok := events[2].(amqppool.ReconnectAttempt); !ok || attempt.Attempt != 1 || attempt.Err == nil {
+		t.Errorf("The third event is different of expected: %#v", events[2])
+	}
+
+	last := events[len(events)-3:]
+	if !reflect.DeepEqual(last[0], amqppool.Reconnected{Attempts: len(events) - 4, Generation: 1}) {
+		t.Errorf("The event of reconnection is inconsistent: %#v", last[0])
+	}
+
+	if discarded, ok := last[1].(amqppool.ChannelDiscarded); !ok || discarded.ID != 1 || discarded.Err.Code != amqp.ConnectionForced {
+		t.Errorf("The event of discard is inconsistent: %#v", last[1])
+	}
+
+	if !reflect.DeepEqual(last[2], amqppool.ChannelCreated{ID: 1, Generation: 1}) {
+		t.Errorf("The event of creation is inconsistent: %#v", last[2])
+	}
+}
+
+func TestShouldSubscribeTheEventsOfBlockAndTimeout(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	subscription, unsubscribe := pool.Subscribe(10)
+	defer unsubscribe()
+
+	inUse, _ := pool.GetReusableChannel()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	//Action
+	broker.Block("low on memory")
+	blocked := next(t, subscription)
+	broker.Unblock()
+	unblocked := next(t, subscription)
+
+	_, _ = pool.GetReusableChannelContext(ctx)
+	timeout := next(t, subscription)
+
+	inUse.Release()
+	_ = pool.Close()
+
+	//Assert
+	if blocked != (amqppool.ConnectionBlocked{Reason: "low on memory"}) || unblocked != (amqppool.ConnectionUnblocked{}) {
+		t.Errorf("The events of block are inconsistent: %#v and %#v", blocked, unblocked)
+	}
+
+	if event, ok := timeout.(amqppool.AcquireTimeout); !ok || event.Err != context.DeadlineExceeded {
+		t.Errorf("The event of timeout is inconsistent: %#v", timeout)
+	}
+
+	if _, open := <-subscription; open {
+		t.Error("The subscription was not closed with the pool")
+	}
+}
+
+func TestShouldDropTheEventsWhenTheBufferOfTheSubscriptionIsFull(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	subscription, unsubscribe := pool.Subscribe(1)
+
+	//Action
+	broker.Block("low on memory")
+	broker.Unblock()
+	eventually(t, func() bool { return len(subscription) == 1 }, "The event was not received")
+	time.Sleep(10 * time.Millisecond)
+	unsubscribe()
+
+	//Assert
+	received := 0
+	for range subscription {
+		received++
+	}
+
+	if received != 1 {
+		t.Errorf("The quantity of events received is inconsistent: Expected 1 and found %v", received)
+	}
+}
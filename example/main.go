@@ -14,7 +14,7 @@ func main() {
 	logger := log.New(os.Stdout, "", log.LstdFlags)
 
 	//Action
-	pool, err := amqppool.NewPool(connectionString, maxChannels, logger)
+	pool, err := amqppool.NewPool(connectionString, maxChannels, amqppool.WithLogger(amqppool.NewStdLogger(logger, false)))
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,30 @@
+package amqppool
+
+//Exposure of the state of the pool and reusable channels to the tests of the package amqppool_test,
+//which can't be internal because import the fake broker of amqppooltest.
+
+//MaxChannels get the maximum quantity of channels of pool
+func (pool *Pool) MaxChannels() int {
+	return pool.maxChannels
+}
+
+//LenChannelsReleased get the quantity of channels released
+func (pool *Pool) LenChannelsReleased() int {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	return len(pool.channelsReleased)
+}
+
+//IsReleased indicates if the reusable channel was released
+func (reusableChannel *ReusableChannel) IsReleased() bool {
+	return reusableChannel.isReleased() != nil
+}
+
+//Generation get the generation of the connection, incremented on each reconnection
+func (pool *Pool) Generation() int {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	return pool.generation
+}
@@ -0,0 +1,131 @@
+package amqppool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+//Ping verify the pool can use the connection with the broker, opening a channel apart of the pool to declare
+//passively the exchange amq.direct
+func (pool *Pool) Ping(ctx context.Context) error {
+	pool.mutex.Lock()
+	connection := pool.connection
+	pool.mutex.Unlock()
+
+	select {
+	case <-pool.closing:
+		return ErrPoolClosed
+	default:
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- ping(connection)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("failed in ping the broker: %w", ctx.Err())
+	}
+}
+
+//ping open a channel in the connection and declare passively the exchange amq.direct
+func ping(connection Connection) error {
+	channel, err := connection.Channel()
+	if err != nil {
+		return fmt.Errorf("failed in open a channel to ping the broker: %w", err)
+	}
+	defer channel.Close()
+
+	if err := channel.ExchangeDeclarePassive("amq.direct", amqp.ExchangeDirect, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed in ping the broker: %w", err)
+	}
+
+	return nil
+}
+
+//HealthCheck the kind of check of the health of a pool
+type HealthCheck string
+
+const (
+	//Liveness check the pool is connected or trying to reconnect, failing when it was closed or gave up of reconnect
+	Liveness HealthCheck = "liveness"
+	//Readiness check the pool can be used, pinging the broker and failing while the connection is blocked
+	Readiness HealthCheck = "readiness"
+)
+
+//Statuses of a HealthReport
+const (
+	HealthUp   = "up"
+	HealthDown = "down"
+)
+
+//HealthReport represents the result of a check of the health of a pool, written by the health handler in JSON
+type HealthReport struct {
+	Check  HealthCheck `json:"check"`           //the kind of check
+	Status string      `json:"status"`          //HealthUp or HealthDown
+	Error  string      `json:"error,omitempty"` //the reason when the status is down
+	Stats  Stats       `json:"stats"`           //the stats of the pool, including the state of the connection
+}
+
+//DefaultHealthTimeout the timeout of the ping of the readiness when the timeout of the health handler is not positive
+const DefaultHealthTimeout = 5 * time.Second
+
+//errConnectionLost the reason of the liveness be down
+var errConnectionLost = errors.New("the pool is closed or gave up of reconnect with the broker")
+
+//healthHandler report the health of a pool in JSON
+type healthHandler struct {
+	pool    *Pool         //pool checked
+	check   HealthCheck   //kind of check
+	timeout time.Duration //timeout of the ping of the readiness
+}
+
+//NewHealthHandler create a http.Handler reporting the health of a pool in JSON, with the status 200 when is up
+//and 503 when is down. The timeout limit the ping of the readiness, DefaultHealthTimeout when it is not positive
+func NewHealthHandler(pool *Pool, check HealthCheck, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		timeout = DefaultHealthTimeout
+	}
+
+	return &healthHandler{pool: pool, check: check, timeout: timeout}
+}
+
+//ServeHTTP implementing the http.Handler interface
+func (handler *healthHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	var err error
+	if handler.check == Readiness {
+		ctx, cancel := context.WithTimeout(request.Context(), handler.timeout)
+		defer cancel()
+
+		err = handler.pool.Ping(ctx)
+		if err == nil {
+			err = handler.pool.blockage.check()
+		}
+	}
+
+	stats := handler.pool.Stats()
+	if handler.check == Liveness && !stats.Connected && !stats.Reconnecting {
+		err = errConnectionLost
+	}
+
+	report := HealthReport{Check: handler.check, Status: HealthUp, Stats: stats}
+	status := http.StatusOK
+	if err != nil {
+		report.Status = HealthDown
+		report.Error = err.Error()
+		status = http.StatusServiceUnavailable
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	_ = json.NewEncoder(writer).Encode(report)
+}
@@ -0,0 +1,203 @@
+package amqppool_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/gmarcial/amqppool/amqppooltest"
+	"github.com/streadway/amqp"
+)
+
+//checkHealth request a health handler and decode its report
+func checkHealth(t *testing.T, handler http.Handler) (int, amqppool.HealthReport) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	var report amqppool.HealthReport
+	if err := json.NewDecoder(recorder.Body).Decode(&report); err != nil {
+		t.Fatalf("Occurred a error to decode the report of health: %v", err.Error())
+	}
+
+	return recorder.Code, report
+}
+
+func TestShouldPingTheBroker(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	reusableChannel, _ := pool.GetReusableChannel()
+	defer reusableChannel.Release()
+
+	//Action
+	err := pool.Ping(context.Background())
+
+	//Assert
+	if err != nil {
+		t.Errorf("Occurred a error to ping the broker with all channels in use: %v", err.Error())
+	}
+}
+
+func TestShouldFailThePingWhenTheConnectionWasLost(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial),
+		amqppool.WithReconnectPolicy(0, time.Hour))
+	defer pool.Close()
+
+	broker.RefuseConnections(nil)
+	broker.KillConnections(amqp.ConnectionForced, "shutdown")
+
+	//Action
+	err := pool.Ping(context.Background())
+
+	//Assert
+	if !errors.Is(err, amqp.ErrClosed) {
+		t.Errorf("The error returned is different of expected: %v", err)
+	}
+}
+
+func TestShouldFailThePingWhenTheContextIsDone(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	broker.SetLatency(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	//Action
+	err := pool.Ping(ctx)
+
+	//Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("The error returned is different of expected: %v", err)
+	}
+}
+
+func TestShouldReportTheHealthOfThePoolUp(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	for _, check := range []amqppool.HealthCheck{amqppool.Liveness, amqppool.Readiness} {
+		//Action
+		status, report := checkHealth(t, amqppool.NewHealthHandler(pool, check, time.Second))
+
+		//Assert
+		if status != http.StatusOK || report.Status != amqppool.HealthUp || report.Check != check {
+			t.Errorf("The %v is inconsistent: %v, %+v", check, status, report)
+		}
+
+		if !report.Stats.Connected || report.Stats.MaxChannels != maxChannels {
+			t.Errorf("The stats of the %v are inconsistent: %+v", check, report.Stats)
+		}
+	}
+}
+
+func TestShouldReportTheReadinessUpWithoutTimeout(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	//Action
+	status, report := checkHealth(t, amqppool.NewHealthHandler(pool, amqppool.Readiness, 0))
+
+	//Assert
+	if status != http.StatusOK || report.Status != amqppool.HealthUp {
+		t.Errorf("The readiness without timeout is inconsistent: %v, %+v", status, report)
+	}
+}
+
+func TestShouldReportTheReadinessDownButTheLivenessUpWhileReconnect(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial),
+		amqppool.WithReconnectPolicy(0, time.Millisecond))
+	defer pool.Close()
+
+	broker.RefuseConnections(nil)
+	broker.KillConnections(amqp.ConnectionForced, "shutdown")
+	eventually(t, func() bool { return pool.Stats().Reconnecting }, "The pool don't started to reconnect")
+
+	//Action
+	readinessStatus, readiness := checkHealth(t, amqppool.NewHealthHandler(pool, amqppool.Readiness, time.Second))
+	livenessStatus, liveness := checkHealth(t, amqppool.NewHealthHandler(pool, amqppool.Liveness, time.Second))
+
+	//Assert
+	if readinessStatus != http.StatusServiceUnavailable || readiness.Status != amqppool.HealthDown || readiness.Error == "" {
+		t.Errorf("The readiness is inconsistent: %v, %+v", readinessStatus, readiness)
+	}
+
+	if livenessStatus != http.StatusOK || !liveness.Stats.Reconnecting {
+		t.Errorf("The liveness is inconsistent: %v, %+v", livenessStatus, liveness)
+	}
+
+	broker.AcceptConnections()
+}
+
+func TestShouldReportTheLivenessDownWhenGaveUpOfReconnect(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial),
+		amqppool.WithReconnectPolicy(1, time.Millisecond))
+	defer pool.Close()
+
+	broker.RefuseConnections(nil)
+	broker.KillConnections(amqp.ConnectionForced, "shutdown")
+	eventually(t, func() bool { stats := pool.Stats(); return !stats.Connected && !stats.Reconnecting },
+		"The pool don't gave up of reconnect")
+
+	//Action
+	status, report := checkHealth(t, amqppool.NewHealthHandler(pool, amqppool.Liveness, time.Second))
+
+	//Assert
+	if status != http.StatusServiceUnavailable || report.Status != amqppool.HealthDown {
+		t.Errorf("The liveness is inconsistent: %v, %+v", status, report)
+	}
+}
+
+func TestShouldReportTheReadinessDownWhileTheConnectionIsBlocked(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	broker.Block("low on memory")
+	defer broker.Unblock()
+	eventually(t, pool.IsBlocked, "The pool was not blocked")
+
+	//Action
+	status, report := checkHealth(t, amqppool.NewHealthHandler(pool, amqppool.Readiness, time.Second))
+
+	//Assert
+	if status != http.StatusServiceUnavailable || !report.Stats.Blocked {
+		t.Errorf("The readiness is inconsistent: %v, %+v", status, report)
+	}
+}
@@ -0,0 +1,129 @@
+package amqppool
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+	"sort"
+	"time"
+)
+
+//Holder represents a reusable channel in use and who got it of the pool
+type Holder struct {
+	ID         int           //the identification of the reusable channel
+	AcquiredAt time.Time     //when the reusable channel was got of the pool
+	HeldFor    time.Duration //how long the reusable channel is in use
+	Stack      string        //the stack trace of who got the reusable channel, only with the leak detection
+}
+
+//ChannelLeakSuspected a reusable channel is in use for longer than the threshold of the leak detection, probably
+//because was not released
+type ChannelLeakSuspected struct {
+	Holder Holder //the reusable channel and who got it
+}
+
+func (ChannelLeakSuspected) event() {}
+
+//WithLeakDetection record the stack trace of who got each reusable channel and warn, by the logger and the event
+//ChannelLeakSuspected, when a reusable channel is in use for longer than the threshold. It's a debug mode, the
+//record of the stack traces has a cost in each get
+func WithLeakDetection(threshold time.Duration) Option {
+	return func(pool *Pool) {
+		pool.leakThreshold = threshold
+	}
+}
+
+//Holders get the reusable channels in use, from the held for longer
+func (pool *Pool) Holders() []Holder {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	now := time.Now()
+	holders := make([]Holder, 0, len(pool.channelsInUse))
+	for _, reusableChannel := range pool.channelsInUse {
+		holders = append(holders, reusableChannel.holder(now))
+	}
+
+	sort.Slice(holders, func(i, j int) bool {
+		return holders[i].AcquiredAt.Before(holders[j].AcquiredAt)
+	})
+
+	return holders
+}
+
+//DumpHolders write the reusable channels in use with the stack traces of who got them
+func (pool *Pool) DumpHolders(writer io.Writer) error {
+	for _, holder := range pool.Holders() {
+		_, err := fmt.Fprintf(writer, "reusable channel %v in use for %v since %v\n%v\n",
+			holder.ID, holder.HeldFor, holder.AcquiredAt.Format(time.RFC3339Nano), holder.Stack)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//trackAcquire record when and by who a reusable channel was got, the lock of the pool must be held
+func (pool *Pool) trackAcquire(reusableChannel *pooledChannel) {
+	reusableChannel.acquiredAt = time.Now()
+	reusableChannel.acquireStack = ""
+	reusableChannel.leakWarned = false
+
+	if pool.leakThreshold > 0 {
+		reusableChannel.acquireStack = string(debug.Stack())
+	}
+}
+
+//holder get the holder of a reusable channel in use, the lock of the pool must be held
+func (reusableChannel *pooledChannel) holder(now time.Time) Holder {
+	return Holder{
+		ID:         reusableChannel.ID,
+		AcquiredAt: reusableChannel.acquiredAt,
+		HeldFor:    now.Sub(reusableChannel.acquiredAt),
+		Stack:      reusableChannel.acquireStack,
+	}
+}
+
+//minLeakInterval the minimum interval between the checks of the leaks, to thresholds too small to a ticker
+const minLeakInterval = 10 * time.Millisecond
+
+//detectLeaks warn periodically the reusable channels in use for longer than the threshold, once by each get
+func detectLeaks(pool *Pool) {
+	interval := pool.leakThreshold / 2
+	if interval < minLeakInterval {
+		interval = minLeakInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pool.done:
+			return
+		case now := <-ticker.C:
+			for _, holder := range pool.suspectLeaks(now) {
+				pool.logger.Warn("reusable channel in use for longer than the threshold, probably was not released",
+					"channel_id", holder.ID, "held_for", holder.HeldFor, "stack", holder.Stack)
+				pool.events.emit(ChannelLeakSuspected{Holder: holder})
+			}
+		}
+	}
+}
+
+//suspectLeaks get the reusable channels in use for longer than the threshold that were not warned
+func (pool *Pool) suspectLeaks(now time.Time) []Holder {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	var suspects []Holder
+	for _, reusableChannel := range pool.channelsInUse {
+		if !reusableChannel.leakWarned && now.Sub(reusableChannel.acquiredAt) > pool.leakThreshold {
+			reusableChannel.leakWarned = true
+			suspects = append(suspects, reusableChannel.holder(now))
+		}
+	}
+
+	return suspects
+}
@@ -0,0 +1,115 @@
+package amqppool_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/gmarcial/amqppool/amqppooltest"
+)
+
+//acquireLeaking get a reusable channel without release it
+func acquireLeaking(pool *amqppool.Pool) *amqppool.ReusableChannel {
+	reusableChannel, _ := pool.GetReusableChannel()
+	return reusableChannel
+}
+
+func TestShouldWarnTheReusableChannelsInUseForLongerThanTheThreshold(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+	recorder := &recorder{}
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial),
+		amqppool.WithLeakDetection(20*time.Millisecond), amqppool.WithHook(recorder.hook))
+	defer pool.Close()
+
+	//Action
+	leaked := acquireLeaking(pool)
+	defer leaked.Release()
+
+	//Assert
+	suspected := func() []amqppool.ChannelLeakSuspected {
+		var suspected []amqppool.ChannelLeakSuspected
+		for _, event := range recorder.recorded() {
+			if leak, ok := event.(amqppool.ChannelLeakSuspected); ok {
+				suspected = append(suspected, leak)
+			}
+		}
+		return suspected
+	}
+	eventually(t, func() bool { return len(suspected()) > 0 }, "The reusable channel leaked was not warned")
+
+	time.Sleep(40 * time.Millisecond)
+	if len(suspected()) != 1 {
+		t.Errorf("The reusable channel leaked was warned more than once: %v", len(suspected()))
+	}
+
+	holder := suspected()[0].Holder
+	if holder.ID != leaked.ID || holder.HeldFor < 20*time.Millisecond || !strings.Contains(holder.Stack, "acquireLeaking") {
+		t.Errorf("The holder of the reusable channel leaked is inconsistent: %+v", holder)
+	}
+}
+
+func TestShouldWarnTheLeaksWithAThresholdSmallerThanTheInterval(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+	recorder := &recorder{}
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial),
+		amqppool.WithLeakDetection(1), amqppool.WithHook(recorder.hook))
+	defer pool.Close()
+
+	//Action
+	leaked := acquireLeaking(pool)
+	defer leaked.Release()
+
+	//Assert
+	eventually(t, func() bool {
+		for _, event := range recorder.recorded() {
+			if _, ok := event.(amqppool.ChannelLeakSuspected); ok {
+				return true
+			}
+		}
+		return false
+	}, "The reusable channel leaked was not warned")
+}
+
+func TestShouldDumpTheHoldersOfTheReusableChannelsInUse(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial),
+		amqppool.WithLeakDetection(time.Hour))
+	defer pool.Close()
+
+	first := acquireLeaking(pool)
+	defer first.Release()
+	second, _ := pool.GetReusableChannel()
+	defer second.Release()
+
+	output := &bytes.Buffer{}
+
+	//Action
+	err := pool.DumpHolders(output)
+
+	//Assert
+	if err != nil {
+		t.Fatalf("Occurred a error to dump the holders: %v", err.Error())
+	}
+
+	holders := pool.Holders()
+	if len(holders) != 2 || holders[0].ID != first.ID || holders[1].ID != second.ID {
+		t.Fatalf("The holders are inconsistent: %+v", holders)
+	}
+
+	dump := output.String()
+	if strings.Count(dump, "reusable channel ") != 2 || !strings.Contains(dump, "acquireLeaking") ||
+		!strings.Contains(dump, "TestShouldDumpTheHoldersOfTheReusableChannelsInUse") {
+		t.Errorf("The dump of the holders is inconsistent: %v", dump)
+	}
+}
@@ -0,0 +1,108 @@
+package amqppool
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+//Logger log the events of the pool with levels and fields, passed how pairs of key and value
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+//nopLogger a Logger that discard the events, used when the pool don't have a logger
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+
+func (nopLogger) Info(string, ...interface{}) {}
+
+func (nopLogger) Warn(string, ...interface{}) {}
+
+func (nopLogger) Error(string, ...interface{}) {}
+
+//WithLogger configure a logger of the events of the pool, by default the events are discarded
+func WithLogger(logger Logger) Option {
+	return func(pool *Pool) {
+		pool.logger = logger
+	}
+}
+
+//StdLogger adapt a *log.Logger to Logger, writing the level, the message and the fields in logfmt style
+type StdLogger struct {
+	logger   *log.Logger //logger of the events
+	minLevel int         //the minimum level logged
+}
+
+//levels of the events of StdLogger
+const (
+	levelDebug = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var levelNames = [...]string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+//NewStdLogger create a new StdLogger logging from the level info, or from debug when debug is true
+func NewStdLogger(logger *log.Logger, debug bool) *StdLogger {
+	minLevel := levelInfo
+	if debug {
+		minLevel = levelDebug
+	}
+
+	return &StdLogger{logger: logger, minLevel: minLevel}
+}
+
+//Debug implementing the Logger interface
+func (logger *StdLogger) Debug(msg string, keysAndValues ...interface{}) {
+	logger.log(levelDebug, msg, keysAndValues)
+}
+
+//Info implementing the Logger interface
+func (logger *StdLogger) Info(msg string, keysAndValues ...interface{}) {
+	logger.log(levelInfo, msg, keysAndValues)
+}
+
+//Warn implementing the Logger interface
+func (logger *StdLogger) Warn(msg string, keysAndValues ...interface{}) {
+	logger.log(levelWarn, msg, keysAndValues)
+}
+
+//Error implementing the Logger interface
+func (logger *StdLogger) Error(msg string, keysAndValues ...interface{}) {
+	logger.log(levelError, msg, keysAndValues)
+}
+
+//log write an event when its level is enabled
+func (logger *StdLogger) log(level int, msg string, keysAndValues []interface{}) {
+	if level < logger.minLevel {
+		return
+	}
+
+	var line strings.Builder
+	fmt.Fprintf(&line, "level=%v msg=%q", levelNames[level], msg)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		var value interface{} = "!MISSING"
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+		fmt.Fprintf(&line, " %v=%v", keysAndValues[i], quoteValue(value))
+	}
+
+	logger.logger.Print(line.String())
+}
+
+//quoteValue quote the values with spaces or quotes to keep the line parseable
+func quoteValue(value interface{}) string {
+	text := fmt.Sprint(value)
+	if strings.ContainsAny(text, " \"=") {
+		return fmt.Sprintf("%q", text)
+	}
+
+	return text
+}
@@ -0,0 +1,43 @@
+package amqppool
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestShouldLogTheEventsWithTheLevelAndTheFields(t *testing.T) {
+	//Arrange
+	output := &bytes.Buffer{}
+	logger := NewStdLogger(log.New(output, "", 0), false)
+
+	//Action
+	logger.Debug("reusable channel released", "channel_id", 1)
+	logger.Warn("connection with the broker closed, reconnecting", "code", 320, "reason", "CONNECTION_FORCED - shutdown")
+	logger.Error("gave up of reconnect with the broker", "attempts")
+
+	//Assert
+	expected := []string{
+		`level=WARN msg="connection with the broker closed, reconnecting" code=320 reason="CONNECTION_FORCED - shutdown"`,
+		`level=ERROR msg="gave up of reconnect with the broker" attempts=!MISSING`,
+	}
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("The events logged are inconsistent: Expected %q and found %q", expected, lines)
+	}
+}
+
+func TestShouldLogTheEventsOfDebugWhenEnabled(t *testing.T) {
+	//Arrange
+	output := &bytes.Buffer{}
+	logger := NewStdLogger(log.New(output, "", 0), true)
+
+	//Action
+	logger.Debug("reusable channel released", "channel_id", 1)
+
+	//Assert
+	if line := strings.TrimSpace(output.String()); line != `level=DEBUG msg="reusable channel released" channel_id=1` {
+		t.Errorf("The event of debug is inconsistent: %q", line)
+	}
+}
@@ -0,0 +1,127 @@
+package amqppool
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+//PublishFunc publish a message, the next step of a PublishMiddleware
+type PublishFunc func(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+
+//PublishMiddleware wrap the publishes of the reusable channels of a pool, e.g. to add headers, validate or measure
+//the messages, calling the next step to continue the publish or returning an error to stop it
+type PublishMiddleware func(next PublishFunc) PublishFunc
+
+//WithPublishMiddleware add middlewares which every publish through the reusable channels of the pool pass, in the
+//order added, the first see the message before the others. Only the undecodable messages sent to their destination
+//by the Consumer don't pass them, to be forwarded how received
+func WithPublishMiddleware(middlewares ...PublishMiddleware) Option {
+	return func(pool *Pool) {
+		pool.publishMiddlewares = append(pool.publishMiddlewares, middlewares...)
+	}
+}
+
+//chainPublish wrap a publish with the middlewares, the first is the outermost
+func chainPublish(middlewares []PublishMiddleware, publish PublishFunc) PublishFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		publish = middlewares[i](publish)
+	}
+
+	return publish
+}
+
+//TransformPublishing create a middleware which transform the messages before they are published, e.g. the
+//compression of the package amqppoolcompress or the encryption of the package amqppoolcrypto
+func TransformPublishing(transform func(msg amqp.Publishing) (amqp.Publishing, error)) PublishMiddleware {
+	return func(next PublishFunc) PublishFunc {
+		return func(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+			msg, err := transform(msg)
+			if err != nil {
+				return err
+			}
+
+			return next(ctx, exchange, key, mandatory, immediate, msg)
+		}
+	}
+}
+
+//MessageID set the MessageId of the messages without one, generated by NewUUID, NewULID or other generator
+func MessageID(generate func() string) PublishMiddleware {
+	return TransformPublishing(func(msg amqp.Publishing) (amqp.Publishing, error) {
+		if msg.MessageId == "" {
+			msg.MessageId = generate()
+		}
+
+		return msg, nil
+	})
+}
+
+//Timestamp set the Timestamp of the messages without one to the time of the publish
+func Timestamp() PublishMiddleware {
+	return TransformPublishing(func(msg amqp.Publishing) (amqp.Publishing, error) {
+		if msg.Timestamp.IsZero() {
+			msg.Timestamp = time.Now()
+		}
+
+		return msg, nil
+	})
+}
+
+//AppID set the AppId of the messages without one
+func AppID(appID string) PublishMiddleware {
+	return TransformPublishing(func(msg amqp.Publishing) (amqp.Publishing, error) {
+		if msg.AppId == "" {
+			msg.AppId = appID
+		}
+
+		return msg, nil
+	})
+}
+
+//DeliveryMode set the DeliveryMode of the messages without one, amqp.Transient or amqp.Persistent
+func DeliveryMode(mode uint8) PublishMiddleware {
+	return TransformPublishing(func(msg amqp.Publishing) (amqp.Publishing, error) {
+		if msg.DeliveryMode == 0 {
+			msg.DeliveryMode = mode
+		}
+
+		return msg, nil
+	})
+}
+
+//NewUUID generate a random UUID of version 4
+func NewUUID() string {
+	var uuid [16]byte
+	_, _ = rand.Read(uuid[:])
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+}
+
+//crockford the alphabet of the base32 of Crockford, of the ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+//NewULID generate an ULID, sortable by the millisecond of its generation, with 80 random bits
+func NewULID() string {
+	var ulid [16]byte
+	binary.BigEndian.PutUint64(ulid[:8], uint64(time.Now().UnixNano()/int64(time.Millisecond))<<16)
+	_, _ = rand.Read(ulid[6:])
+
+	//the 128 bits are encoded in 26 characters of 5 bits, the first character with only 3 bits
+	high := binary.BigEndian.Uint64(ulid[:8])
+	low := binary.BigEndian.Uint64(ulid[8:])
+	encoded := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		encoded[i] = crockford[low&0x1f]
+		low = low>>5 | high<<59
+		high >>= 5
+	}
+
+	return string(encoded)
+}
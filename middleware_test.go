@@ -0,0 +1,114 @@
+package amqppool_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/gmarcial/amqppool/amqppooltest"
+	"github.com/streadway/amqp"
+)
+
+//trail create a middleware which record its name in the order the publishes pass
+func trail(name string, passed *[]string) amqppool.PublishMiddleware {
+	return func(next amqppool.PublishFunc) amqppool.PublishFunc {
+		return func(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+			*passed = append(*passed, name)
+			return next(ctx, exchange, key, mandatory, immediate, msg)
+		}
+	}
+}
+
+func TestShouldSetTheDefaultsOfTheMessagesPublishedThroughThePool(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial),
+		amqppool.WithPublishMiddleware(amqppool.MessageID(amqppool.NewULID), amqppool.Timestamp(),
+			amqppool.AppID("orders-api"), amqppool.DeliveryMode(amqp.Persistent)))
+	defer pool.Close()
+
+	reusableChannel, _ := pool.GetReusableChannel()
+	defer reusableChannel.Release()
+	_, _ = reusableChannel.QueueDeclare("orders", false, false, false, false, nil)
+
+	//Action
+	err := pool.Publish(context.Background(), "", "orders", false, false, amqp.Publishing{Body: []byte("order")})
+	keptErr := reusableChannel.Publish("", "orders", false, false, amqp.Publishing{MessageId: "order-1", AppId: "billing",
+		DeliveryMode: amqp.Transient})
+
+	//Assert
+	if err != nil || keptErr != nil {
+		t.Fatalf("Occurred a error to publish: %v, %v", err, keptErr)
+	}
+
+	defaulted, _, _ := reusableChannel.Get("orders", true)
+	if len(defaulted.MessageId) != 26 || defaulted.Timestamp.IsZero() || defaulted.AppId != "orders-api" ||
+		defaulted.DeliveryMode != amqp.Persistent {
+		t.Errorf("The defaults of the message were not set: %+v", defaulted)
+	}
+
+	kept, _, _ := reusableChannel.Get("orders", true)
+	if kept.MessageId != "order-1" || kept.AppId != "billing" || kept.DeliveryMode != amqp.Transient {
+		t.Errorf("The properties of the message were replaced: %+v", kept)
+	}
+}
+
+func TestShouldPassThePublishesThroughTheMiddlewaresInOrder(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+	var passed []string
+	invalid := errors.New("the message don't have body")
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial),
+		amqppool.WithPublishMiddleware(trail("first", &passed), trail("second", &passed)),
+		amqppool.WithPublishMiddleware(amqppool.TransformPublishing(func(msg amqp.Publishing) (amqp.Publishing, error) {
+			if len(msg.Body) == 0 {
+				return msg, invalid
+			}
+			return msg, nil
+		})))
+	defer pool.Close()
+
+	declarer, _ := pool.GetReusableChannel()
+	_, _ = declarer.QueueDeclare("orders", false, false, false, false, nil)
+	_ = declarer.Release()
+
+	//Action
+	err := pool.Publish(context.Background(), "", "orders", false, false, amqp.Publishing{})
+
+	//Assert
+	if !errors.Is(err, invalid) || broker.MessageCount("orders") != 0 {
+		t.Errorf("The publish was not stopped by the middleware: %v", err)
+	}
+
+	if len(passed) != 2 || passed[0] != "first" || passed[1] != "second" {
+		t.Errorf("The order of the middlewares is inconsistent: %v", passed)
+	}
+}
+
+func TestShouldGenerateTheIdsOfTheMessages(t *testing.T) {
+	//Arrange
+	uuidFormat := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulidFormat := regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+
+	//Action
+	uuid := amqppool.NewUUID()
+	first := amqppool.NewULID()
+	time.Sleep(2 * time.Millisecond)
+	second := amqppool.NewULID()
+
+	//Assert
+	if !uuidFormat.MatchString(uuid) || uuid == amqppool.NewUUID() {
+		t.Errorf("The UUID generated is inconsistent: %v", uuid)
+	}
+
+	if !ulidFormat.MatchString(first) || !ulidFormat.MatchString(second) || first >= second {
+		t.Errorf("The ULIDs generated are inconsistent or not sortable: %v, %v", first, second)
+	}
+}
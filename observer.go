@@ -0,0 +1,35 @@
+package amqppool
+
+import (
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+//Observer is notified of the operations of a pool to measure them, e.g. by the collector of the package
+//amqppoolprometheus. The methods are called synchronously, some with the lock of the pool held, so they must
+//be fast and must not call the pool
+type Observer interface {
+	//ObserveAcquire is notified when a reusable channel is got of the pool, with the time waited and the error, if any
+	ObserveAcquire(wait time.Duration, err error)
+	//ObservePublish is notified when a message is published by a reusable channel, with its latency and the error, if any
+	ObservePublish(latency time.Duration, err error)
+	//ObserveChannelError is notified when is found a channel of the pool closed by an exception of the broker
+	ObserveChannelError(err *amqp.Error)
+}
+
+//nopObserver an Observer that ignore the notifications, used when the pool don't have an observer
+type nopObserver struct{}
+
+func (nopObserver) ObserveAcquire(time.Duration, error) {}
+
+func (nopObserver) ObservePublish(time.Duration, error) {}
+
+func (nopObserver) ObserveChannelError(*amqp.Error) {}
+
+//WithObserver configure an observer to be notified of the operations of the pool
+func WithObserver(observer Observer) Option {
+	return func(pool *Pool) {
+		pool.observer = observer
+	}
+}
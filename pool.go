@@ -1,95 +1,363 @@
 package amqppool
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/streadway/amqp"
-	"log"
+	"sync"
+	"time"
 )
 
 //Pool represents a connection and manage the pool of reusable channels
 type Pool struct {
-	connection                  *amqp.Connection         //the connection amqp
-	maxChannels                 int                      //the maximum quantity of channels of pool
-	channelRelease              chan int                 //a go channel to listen when a reusable channel was released
-	connectionCloseNotification chan *amqp.Error         //a go channel to listen when the connection amqp was closed
-	channelsInUse               map[int]*ReusableChannel //in use channels store
-	channelsReleased            map[int]*ReusableChannel //released channels store
+	connection                  Connection             //the connection amqp
+	dialer                      Dialer                 //establish the connection with the broker amqp
+	maxChannels                 int                    //the maximum quantity of channels of pool
+	channelRelease              chan int               //a go channel to listen when a reusable channel was released
+	connectionCloseNotification chan *amqp.Error       //a go channel to listen when the connection amqp was closed
+	channelsInUse               map[int]*pooledChannel //in use channels store
+	channelsReleased            map[int]*pooledChannel //released channels store
+	mutex                       sync.Mutex             //guard the stores of channels and the connection
+	generation                  int                    //incremented on each reconnection, to renew the channels of old connections
+	reconnectAttempts           int                    //the maximum of attempts to reconnect, 0 is unlimited
+	reconnectDelay              time.Duration          //the delay between the attempts to reconnect
+	closing                     chan struct{}          //a go channel closed when the pool stop of hand out channels
+	done                        chan struct{}          //a go channel closed when the pool is closed
+	workers                     sync.WaitGroup         //the goroutines of background of the pool
+	releaseSignal               chan struct{}          //a go channel closed and replaced when a reusable channel is released
+	counters                    counters               //the usage of the pool to the stats
+	observer                    Observer               //notified of the operations of the pool
+	logger                      Logger                 //log the events of the pool
+	events                      events                 //dispatch the events of the lifecycle of the pool
+	blockage                    *blockage              //indicates when the connection is blocked by the broker
+	connected                   bool                   //indicates when the pool is connected with the broker
+	reconnecting                bool                   //indicates when the pool is trying to reconnect with the broker
+	leakThreshold               time.Duration          //the time in use from which a reusable channel is warned how leaked, 0 disable
+	lastID                      int                    //the last identification allocated to a reusable channel
+	connectionErr               *amqp.Error            //the exception which closed the connection, while reconnecting
+	reconnectErr                error                  //the error of when the pool gave up of reconnect
+	publishMiddlewares          []PublishMiddleware    //wrap the publishes of the reusable channels
+}
+
+//Option configure a Pool in its creation
+type Option func(pool *Pool)
+
+//WithDialer replace the dialer of the connection amqp, by default the streadway/amqp client
+func WithDialer(dialer Dialer) Option {
+	return func(pool *Pool) {
+		pool.dialer = dialer
+	}
+}
+
+//WithReconnectPolicy configure the reconnection when the connection amqp is lost, by default the attempts are
+//unlimited with a delay of 1 second between them, maxAttempts 0 is unlimited
+func WithReconnectPolicy(maxAttempts int, delay time.Duration) Option {
+	return func(pool *Pool) {
+		pool.reconnectAttempts = maxAttempts
+		pool.reconnectDelay = delay
+	}
 }
 
 //NewPool create a new Pool
-func NewPool(connectionString string, maxChannels int, logger *log.Logger) (*Pool, error) {
-	connection, err := connect(connectionString)
+func NewPool(connectionString string, maxChannels int, options ...Option) (*Pool, error) {
+	pool := &Pool{
+		dialer:         connect,
+		maxChannels:    maxChannels,
+		reconnectDelay: time.Second,
+		closing:        make(chan struct{}),
+		done:           make(chan struct{}),
+		releaseSignal:  make(chan struct{}),
+		observer:       nopObserver{},
+		logger:         nopLogger{},
+		blockage:       &blockage{},
+	}
+
+	for _, option := range options {
+		option(pool)
+	}
+
+	connection, err := pool.dialer(connectionString)
 	if err != nil {
 		return nil, err
 	}
-	reusableChannels := make(map[int]*ReusableChannel, 0)
+	reusableChannels := make(map[int]*pooledChannel, 0)
 	channelRelease := make(chan int)
 
-	for id := 1; id <= maxChannels; id++ {
-		reusableChannel, err := newReusableChannel(id, connection, channelRelease)
+	for i := 0; i < maxChannels; i++ {
+		id := pool.nextID()
+		reusableChannel, err := newReusableChannel(id, connection, 0, channelRelease)
 		if err != nil {
 			return nil, err
 		}
+		pool.adopt(reusableChannel)
 
 		reusableChannels[id] = reusableChannel
+		pool.counters.channelsCreated++
+		pool.events.queue(ChannelCreated{ID: id})
 	}
 
-	connectionCloseNotification := make(chan *amqp.Error)
+	connectionCloseNotification := make(chan *amqp.Error, 1)
 	connectionCloseNotification = connection.NotifyClose(connectionCloseNotification)
 
-	pool := &Pool{
-		connection:                  connection,
-		maxChannels:                 maxChannels,
-		channelRelease:              channelRelease,
-		connectionCloseNotification: connectionCloseNotification,
-		channelsReleased:            reusableChannels,
-		channelsInUse:               make(map[int]*ReusableChannel, 0),
+	pool.connection = connection
+	pool.channelRelease = channelRelease
+	pool.connectionCloseNotification = connectionCloseNotification
+	pool.channelsReleased = reusableChannels
+	pool.channelsInUse = make(map[int]*pooledChannel, 0)
+	pool.connected = true
+
+	pool.work(func() { listenWhenConnectionClose(connectionString, pool) })
+	pool.work(func() { listenWhenChannelRelease(pool) })
+	connectionBlockNotification := connection.NotifyBlocked(make(chan amqp.Blocking, 1))
+	pool.work(func() { listenWhenConnectionBlocked(pool, connectionBlockNotification, 0) })
+	if pool.leakThreshold > 0 {
+		pool.work(func() { detectLeaks(pool) })
 	}
-
-	go listenWhenConnectionClose(connectionString, pool, logger)
-	go listenWhenChannelRelease(pool, logger)
+	pool.events.emit()
 
 	return pool, nil
 }
 
-//connect establish the connection with the broker amqp
-func connect(connectionString string) (*amqp.Connection, error) {
+//connect establish the connection with the broker amqp, the default dialer of the pool
+func connect(connectionString string) (Connection, error) {
 	connection, err := amqp.Dial(connectionString)
 	if err != nil {
 		return nil, err
 	}
 
-	return connection, nil
+	return &amqpConnection{connection: connection}, nil
 }
 
-//Close close the connection with the broker amqp
+//Close close the connection with the broker amqp immediately, without wait the reusable channels in use be released,
+//to close gracefully use Shutdown
 func (pool *Pool) Close() error {
-	connection := pool.connection
+	pool.mutex.Lock()
+	if !pool.stopHandOut() {
+		pool.mutex.Unlock()
+		return ErrPoolClosed
+	}
+	connection := pool.stop()
+	pool.mutex.Unlock()
 
-	if err := connection.Close(); err != nil {
-		errMsg := fmt.Sprintf("Occurred an error to try close the connection with the amqp broker: %v", err.Error())
-		return fmt.Errorf(errMsg)
+	err := closeConnection(connection)
+	pool.events.close()
+
+	return err
+}
+
+//Shutdown close the pool gracefully: stop of hand out reusable channels, wait the channels in use be released until
+//the context is done, close each channel cleanly, what flushes the confirms pending of the broker, then the connection,
+//and wait the goroutines of background of the pool stop. When the context is done before, the channels still in use
+//are closed with the connection and the error of the context is returned
+func (pool *Pool) Shutdown(ctx context.Context) error {
+	pool.mutex.Lock()
+	if !pool.stopHandOut() {
+		pool.mutex.Unlock()
+		return ErrPoolClosed
+	}
+
+	var err error
+	for len(pool.channelsInUse) > 0 && err == nil {
+		releaseSignal := pool.releaseSignal
+		pool.mutex.Unlock()
+
+		select {
+		case <-releaseSignal:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+
+		pool.mutex.Lock()
+	}
+	inUse := len(pool.channelsInUse)
+	channelsReleased := make([]*pooledChannel, 0, len(pool.channelsReleased))
+	for _, reusableChannel := range pool.channelsReleased {
+		channelsReleased = append(channelsReleased, reusableChannel)
+	}
+	generation := pool.generation
+	connection := pool.stop()
+	pool.mutex.Unlock()
+
+	if err != nil {
+		pool.logger.Warn("shutdown without wait the reusable channels in use", "in_use", inUse)
+		err = fmt.Errorf("failed in wait %v reusable channels in use to shutdown the pool: %w", inUse, err)
+	}
+
+	for _, reusableChannel := range channelsReleased {
+		if stale, _ := reusableChannel.isStale(generation); stale {
+			continue
+		}
+
+		if closeErr := reusableChannel.channel.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed in close the channel of id %v: %w", reusableChannel.ID, closeErr)
+		}
+	}
+
+	if closeErr := closeConnection(connection); closeErr != nil && err == nil {
+		err = closeErr
 	}
 
-	close(pool.channelRelease)
+	stopped := make(chan struct{})
+	go func() {
+		pool.workers.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		if err == nil {
+			err = ctx.Err()
+		}
+	}
+	pool.events.close()
+
+	return err
+}
+
+//stopHandOut stop of hand out reusable channels, waking who wait one, false when the pool was already closed, the
+//lock of the pool must be held
+func (pool *Pool) stopHandOut() bool {
+	select {
+	case <-pool.closing:
+		return false
+	default:
+		close(pool.closing)
+		return true
+	}
+}
+
+//stop signal the goroutines of background of the pool to stop and get the connection to close, the lock of the pool
+//must be held
+func (pool *Pool) stop() Connection {
+	close(pool.done)
+	pool.connected = false
+	pool.reconnecting = false
+
+	return pool.connection
+}
+
+//closeConnection close the connection with the broker amqp
+func closeConnection(connection Connection) error {
+	if err := connection.Close(); err != nil {
+		return fmt.Errorf("Occurred an error to try close the connection with the amqp broker: %w", err)
+	}
 
 	return nil
 }
 
+//work run a goroutine of background of the pool, waited by Shutdown
+func (pool *Pool) work(routine func()) {
+	pool.workers.Add(1)
+	go func() {
+		defer pool.workers.Done()
+		routine()
+	}()
+}
+
 //GetReusableChannel get a reusable channel of the pool to use
 func (pool *Pool) GetReusableChannel() (*ReusableChannel, error) {
+	start := time.Now()
+
+	pool.mutex.Lock()
+	reusableChannel, err := pool.acquire()
+	pool.mutex.Unlock()
+
+	pool.observer.ObserveAcquire(time.Since(start), err)
+	pool.events.emit()
+
+	return reusableChannel, err
+}
+
+//GetReusableChannelContext get a reusable channel of the pool to use, waiting one be released when all are in use
+//until the context is done
+func (pool *Pool) GetReusableChannelContext(ctx context.Context) (reusableChannel *ReusableChannel, err error) {
+	start := time.Now()
+	defer func() {
+		pool.observer.ObserveAcquire(time.Since(start), err)
+		pool.events.emit()
+	}()
+
+	pool.mutex.Lock()
+	reusableChannel, err = pool.acquire()
+	if !errors.Is(err, ErrAllChannelsInUse) {
+		pool.mutex.Unlock()
+		return reusableChannel, err
+	}
+
+	pool.counters.waits++
+	defer func() {
+		pool.mutex.Lock()
+		pool.counters.waitDuration += time.Since(start)
+		pool.mutex.Unlock()
+	}()
+
+	for errors.Is(err, ErrAllChannelsInUse) {
+		releaseSignal := pool.releaseSignal
+		pool.mutex.Unlock()
+
+		select {
+		case <-releaseSignal:
+		case <-pool.closing:
+			return nil, ErrPoolClosed
+		case <-ctx.Done():
+			pool.mutex.Lock()
+			pool.counters.timeouts++
+			pool.mutex.Unlock()
+			waited := time.Since(start)
+			pool.events.queue(AcquireTimeout{Waited: waited, Err: ctx.Err()})
+			return nil, &AcquireTimeoutError{Waited: waited, Err: ctx.Err()}
+		}
+
+		pool.mutex.Lock()
+		reusableChannel, err = pool.acquire()
+	}
+	pool.mutex.Unlock()
+
+	return reusableChannel, err
+}
+
+//acquire get a reusable channel released or create a new while don't hit the maximum, the lock of the pool must be held
+func (pool *Pool) acquire() (*ReusableChannel, error) {
+	select {
+	case <-pool.closing:
+		return nil, ErrPoolClosed
+	default:
+	}
+
+	if pool.reconnectErr != nil {
+		return nil, pool.reconnectErr
+	}
+	if pool.reconnecting {
+		return nil, &ConnectionClosedError{Err: pool.connectionErr}
+	}
+
 	channelsReleased := pool.channelsReleased
 	lenChannelsReleased := len(channelsReleased)
 	lenChannelsInUse := len(pool.channelsInUse)
 
-	var reusableChannel *ReusableChannel
+	var reusableChannel *pooledChannel
+	var handle *ReusableChannel
 	if lenChannelsReleased > 0 {
 		for _, channel := range channelsReleased {
 			reusableChannel = channel
-			pool.addReusableChannelToUse(reusableChannel)
 			break
 		}
+
+		stale, closeErr := reusableChannel.isStale(pool.generation)
+		if closeErr != nil && reusableChannel.generation == pool.generation {
+			pool.observer.ObserveChannelError(closeErr)
+		}
+
+		if stale {
+			pool.events.queue(ChannelDiscarded{ID: reusableChannel.ID, Err: closeErr})
+			if err := pool.openChannel(reusableChannel); err != nil {
+				return nil, err
+			}
+		}
+
+		handle = pool.addReusableChannelToUse(reusableChannel)
 	} else if (lenChannelsReleased == 0) && (lenChannelsInUse < pool.maxChannels) {
 		newReusableChannel, err := pool.newReusableChannelToUso()
 		reusableChannel = newReusableChannel
@@ -97,25 +365,29 @@ func (pool *Pool) GetReusableChannel() (*ReusableChannel, error) {
 			return nil, err
 		}
 
-		pool.addReusableChannelToUse(reusableChannel)
+		handle = pool.addReusableChannelToUse(reusableChannel)
 	} else {
 		return nil, ErrAllChannelsInUse
 	}
+	pool.counters.acquires++
 
-	return reusableChannel, nil
+	return handle, nil
 }
 
 //newReusableChannel create a new reusable channel released
-func newReusableChannel(id int, connection *amqp.Connection, channelRelease chan int) (*ReusableChannel, error) {
+func newReusableChannel(id int, connection Connection, generation int, channelRelease chan int) (*pooledChannel, error) {
 	channel, err := connection.Channel()
 	if err != nil {
 		return nil, err
 	}
 
-	reusableChannel := &ReusableChannel{
+	reusableChannel := &pooledChannel{
 		ID:             id,
 		released:       true,
 		channel:        channel,
+		number:         channelNumber(channel),
+		channelClose:   channel.NotifyClose(make(chan *amqp.Error, 1)),
+		generation:     generation,
 		channelRelease: channelRelease,
 	}
 
@@ -123,64 +395,280 @@ func newReusableChannel(id int, connection *amqp.Connection, channelRelease chan
 }
 
 //newReusableChannelToUso create a new reusable channel for now use
-func (pool *Pool) newReusableChannelToUso() (*ReusableChannel, error) {
-	ID := (len(pool.channelsReleased) + len(pool.channelsInUse)) + 1
-	return newReusableChannel(ID, pool.connection, pool.channelRelease)
+func (pool *Pool) newReusableChannelToUso() (*pooledChannel, error) {
+	ID := pool.nextID()
+	reusableChannel, err := newReusableChannel(ID, pool.connection, pool.generation, pool.channelRelease)
+	if err != nil {
+		return nil, err
+	}
+	pool.adopt(reusableChannel)
+	pool.counters.channelsCreated++
+	pool.events.queue(ChannelCreated{ID: ID, Generation: pool.generation})
+
+	return reusableChannel, nil
+}
+
+//nextID allocate the identification of a new reusable channel, never reused while the pool exist, the lock of the
+//pool must be held
+func (pool *Pool) nextID() int {
+	pool.lastID++
+	return pool.lastID
+}
+
+//renewReusableChannel replace the channel of a reusable channel closed by the broker with a new channel
+func (pool *Pool) renewReusableChannel(reusableChannel *pooledChannel) error {
+	pool.mutex.Lock()
+	err := pool.openChannel(reusableChannel)
+	pool.mutex.Unlock()
+
+	pool.events.emit()
+
+	return err
 }
 
-//addReusableChannelToUse add a reusable channel for now use
-func (pool *Pool) addReusableChannelToUse(reusableChannel *ReusableChannel) {
-	reusableChannel.released = false
+//openChannel open a new channel in the current connection to a reusable channel, the lock of the pool must be held
+func (pool *Pool) openChannel(reusableChannel *pooledChannel) error {
+	channel, err := pool.connection.Channel()
+	if err != nil {
+		return err
+	}
+
+	reusableChannel.channel = channel
+	reusableChannel.mutex.Lock()
+	reusableChannel.number = channelNumber(channel)
+	reusableChannel.broken = nil
+	reusableChannel.mutex.Unlock()
+	reusableChannel.channelClose = channel.NotifyClose(make(chan *amqp.Error, 1))
+	reusableChannel.generation = pool.generation
+	pool.counters.channelsDiscarded++
+	pool.counters.channelsCreated++
+	pool.events.queue(ChannelCreated{ID: reusableChannel.ID, Generation: pool.generation})
+
+	return nil
+}
+
+//adopt share the state of the pool with a new reusable channel
+func (pool *Pool) adopt(reusableChannel *pooledChannel) {
+	reusableChannel.observer = pool.observer
+	reusableChannel.blockage = pool.blockage
+	reusableChannel.poolDone = pool.done
+	reusableChannel.publisher = chainPublish(pool.publishMiddlewares, reusableChannel.send)
+}
+
+//addReusableChannelToUse add a reusable channel for now use, getting the handle of the acquisition
+func (pool *Pool) addReusableChannelToUse(reusableChannel *pooledChannel) *ReusableChannel {
+	handle := reusableChannel.lend()
+	pool.trackAcquire(reusableChannel)
 	pool.channelsInUse[reusableChannel.ID] = reusableChannel
 	delete(pool.channelsReleased, reusableChannel.ID)
+
+	return handle
 }
 
-//Close close a channel and remove of pool
+//CloseReusableChannel close a channel and remove of pool, a channel in use is closed and removed when released
 func (pool *Pool) CloseReusableChannel(id int) error {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if reusableChannel, inUse := pool.channelsInUse[id]; inUse {
+		reusableChannel.closeOnRelease = true
+		return nil
+	}
+
 	reusableChannel, exist := pool.channelsReleased[id]
 	if !exist {
-		errMsg := fmt.Sprintf("don't was found the reusable channel with the id %v in channels released", id)
-		return errors.New(errMsg)
+		return fmt.Errorf("failed to close the reusable channel with the id %v: %w", id, ErrChannelNotFound)
 	}
 
-	channel := reusableChannel.channel
-	if err := channel.Close(); err != nil {
-		errMsg := fmt.Sprintf("Occurred an error to try close the channel of id %v: %v", id, err.Error())
-		return errors.New(errMsg)
-	}
 	delete(pool.channelsReleased, id)
+	pool.counters.channelsClosed++
 
-	return nil
+	return closeChannel(reusableChannel)
 }
 
-//listenWhenChannelRelease stay listen when the channels that are released
-func listenWhenChannelRelease(pool *Pool, logger *log.Logger) {
-	logger.Println("Start listening when reusable channels are released")
+//closeChannel close the channel amqp of a reusable channel
+func closeChannel(reusableChannel *pooledChannel) error {
+	if err := reusableChannel.channel.Close(); err != nil {
+		return fmt.Errorf("Occurred an error to try close the channel of id %v: %w", reusableChannel.ID, err)
+	}
 
-	for reusableChannelID := range pool.channelRelease {
-		reusableChannel := pool.channelsInUse[reusableChannelID]
-		delete(pool.channelsInUse, reusableChannel.ID)
-		pool.channelsReleased[reusableChannel.ID] = reusableChannel
+	return nil
+}
+
+//ChannelNumbers get the number of the channel amqp in the connection of each reusable channel of the pool, by the
+//identification of the reusable channel, 0 when the number is unknown
+func (pool *Pool) ChannelNumbers() map[int]uint16 {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
 
-		logger.Printf("Reusable channel was released %v", reusableChannelID)
+	numbers := make(map[int]uint16, len(pool.channelsReleased)+len(pool.channelsInUse))
+	for id, reusableChannel := range pool.channelsReleased {
+		numbers[id] = reusableChannel.ChannelNumber()
+	}
+	for id, reusableChannel := range pool.channelsInUse {
+		numbers[id] = reusableChannel.ChannelNumber()
 	}
 
-	logger.Println("Stop listening when reusable channels are released")
+	return numbers
+}
+
+//listenWhenChannelRelease stay listen when the channels that are released
+func listenWhenChannelRelease(pool *Pool) {
+	pool.logger.Debug("start listening when reusable channels are released")
+
+	for {
+		var reusableChannelID int
+		select {
+		case reusableChannelID = <-pool.channelRelease:
+		case <-pool.done:
+			pool.logger.Debug("stop listening when reusable channels are released")
+			return
+		}
+
+		pool.mutex.Lock()
+		reusableChannel, inUse := pool.channelsInUse[reusableChannelID]
+		closing := inUse && reusableChannel.closeOnRelease
+		if inUse {
+			delete(pool.channelsInUse, reusableChannel.ID)
+			if closing {
+				pool.counters.channelsClosed++
+			} else {
+				pool.channelsReleased[reusableChannel.ID] = reusableChannel
+			}
+			close(pool.releaseSignal)
+			pool.releaseSignal = make(chan struct{})
+		}
+		pool.mutex.Unlock()
+
+		if closing {
+			if err := closeChannel(reusableChannel); err != nil {
+				pool.logger.Warn("failed to close the reusable channel released", "channel_id", reusableChannelID,
+					"error", err.Error())
+			}
+			pool.logger.Debug("reusable channel closed on release", "channel_id", reusableChannelID)
+		} else if inUse {
+			pool.logger.Debug("reusable channel released", "channel_id", reusableChannelID)
+		} else {
+			pool.logger.Warn("released a reusable channel that was not in use", "channel_id", reusableChannelID)
+		}
+	}
 }
 
 //listenWhenConnectionClose stay listen when the connection amqp close and try to reconnect
-func listenWhenConnectionClose(connectionString string, pool *Pool, logger *log.Logger) {
-	logger.Println("Start listening when the connection amqp close")
+func listenWhenConnectionClose(connectionString string, pool *Pool) {
+	pool.logger.Debug("start listening when the connection amqp close")
+
+	for {
+		pool.mutex.Lock()
+		connectionCloseNotification := pool.connectionCloseNotification
+		generation := pool.generation
+		pool.mutex.Unlock()
 
-	for err := range pool.connectionCloseNotification {
-		logger.Printf("Connection with the broker closed in server %v: %v, %v, try reconnect", err.Server, err.Code, err.Reason)
-		connection, err := connect(connectionString)
+		err, open := <-connectionCloseNotification
+		if !open || err == nil {
+			break
+		}
+
+		pool.mutex.Lock()
+		pool.connected = false
+		pool.reconnecting = true
+		pool.connectionErr = err
+		pool.mutex.Unlock()
+
+		pool.logger.Warn("connection with the broker closed, reconnecting",
+			"code", err.Code, "reason", err.Reason, "server", err.Server)
+		pool.events.emit(ConnectionLost{Err: err, Generation: generation})
+		if !pool.reconnect(connectionString) {
+			pool.mutex.Lock()
+			pool.reconnecting = false
+			pool.mutex.Unlock()
+			break
+		}
+	}
+
+	pool.logger.Debug("stop listening when the connection amqp close")
+}
+
+//reconnect try to establish a new connection according to the reconnect policy, the channels of the old connection
+//are renewed when are got of the pool
+func (pool *Pool) reconnect(connectionString string) bool {
+	var lastErr error
+	for attempt := 1; pool.reconnectAttempts == 0 || attempt <= pool.reconnectAttempts; attempt++ {
+		connection, err := pool.dialer(connectionString)
 		if err != nil {
-			logger.Panic(err.Error())
+			lastErr = err
+			pool.logger.Warn("failed to reconnect with the broker", "attempt", attempt, "error", err.Error())
+			pool.events.emit(ReconnectAttempt{Attempt: attempt, Err: err})
+
+			select {
+			case <-time.After(pool.reconnectDelay):
+				continue
+			case <-pool.done:
+				return false
+			}
+		}
+
+		pool.mutex.Lock()
+		select {
+		case <-pool.done:
+			pool.mutex.Unlock()
+			_ = connection.Close()
+			return false
+		default:
 		}
 
 		pool.connection = connection
+		pool.connectionCloseNotification = connection.NotifyClose(make(chan *amqp.Error, 1))
+		pool.generation++
+		pool.counters.reconnects++
+		generation := pool.generation
+		pool.connected = true
+		pool.reconnecting = false
+		pool.connectionErr = nil
+		pool.blockage.unblock()
+		pool.mutex.Unlock()
+
+		connectionBlockNotification := connection.NotifyBlocked(make(chan amqp.Blocking, 1))
+		pool.work(func() { listenWhenConnectionBlocked(pool, connectionBlockNotification, generation) })
+
+		pool.logger.Info("reconnected with the broker", "attempts", attempt, "generation", generation)
+		pool.events.emit(Reconnected{Attempts: attempt, Generation: generation})
+		return true
 	}
 
-	logger.Println("Stop of listening when the connection amqp close")
+	reconnectErr := &ReconnectExhaustedError{Attempts: pool.reconnectAttempts, Err: lastErr}
+	pool.mutex.Lock()
+	pool.reconnectErr = reconnectErr
+	pool.mutex.Unlock()
+
+	pool.logger.Error("gave up of reconnect with the broker", "attempts", pool.reconnectAttempts)
+	pool.events.emit(ReconnectFailed{Attempts: pool.reconnectAttempts, Err: reconnectErr})
+	return false
+}
+
+//listenWhenConnectionBlocked stay listen when the broker block or unblock the publishes of a connection, until it close,
+//ignoring the notifications of a connection already replaced by a reconnection
+func listenWhenConnectionBlocked(pool *Pool, connectionBlockNotification chan amqp.Blocking, generation int) {
+	for blocking := range connectionBlockNotification {
+		pool.mutex.Lock()
+		current := pool.generation == generation
+		if current && blocking.Active {
+			pool.blockage.block(blocking.Reason)
+		} else if current {
+			pool.blockage.unblock()
+		}
+		pool.mutex.Unlock()
+
+		if !current {
+			continue
+		}
+
+		if blocking.Active {
+			pool.logger.Warn("connection blocked by the broker", "reason", blocking.Reason)
+			pool.events.emit(ConnectionBlocked{Reason: blocking.Reason})
+		} else {
+			pool.logger.Info("connection unblocked by the broker")
+			pool.events.emit(ConnectionUnblocked{})
+		}
+	}
 }
@@ -1,29 +1,123 @@
 package amqppool
 
 import (
+	"errors"
+	"sync"
+	"time"
+
 	"github.com/streadway/amqp"
 )
 
-//ReusableChannel represents a channel amqp that can be reusable
+//ReusableChannel represents a channel amqp that can be reusable, each get of the pool hand out a new
+//ReusableChannel of the channel, which can't be used or released after it is released
 type ReusableChannel struct {
-	ID             int           //identification of a reusable channel
-	released       bool          //indicates when the channel was released
-	channelRelease chan int      //a go channel to notify the pool which the reusable was released
-	channel        *amqp.Channel //channel to be reuse
+	*pooledChannel        //the channel of the pool
+	lease          uint64 //the get of the channel which handed out the ReusableChannel
+}
+
+//pooledChannel the channel of a pool, shared by the ReusableChannel of each get
+type pooledChannel struct {
+	ID             int              //identification of a reusable channel
+	mutex          sync.Mutex       //guard the indications of released and broken, the lease and the number
+	released       bool             //indicates when the channel was released
+	lease          uint64           //the current get of the channel, incremented on each get
+	channelRelease chan int         //a go channel to notify the pool which the reusable was released
+	poolDone       chan struct{}    //a go channel closed when the pool is closed
+	channel        Channel          //channel to be reuse
+	number         uint16           //the number of the channel amqp in its connection, 0 when unknown
+	channelClose   chan *amqp.Error //a go channel to listen when the channel amqp was closed
+	generation     int              //the generation of the connection of the channel
+	observer       Observer         //notified of the operations of the channel
+	blockage       *blockage        //indicates when the connection of the channel is blocked
+	acquiredAt     time.Time        //when the channel was got of the pool
+	acquireStack   string           //the stack trace of who got the channel, only with the leak detection
+	leakWarned     bool             //indicates when the channel was warned how leaked
+	closeOnRelease bool             //indicates when the channel must be closed and removed of pool when released
+	broken         *amqp.Error      //the exception returned by an operation which closed the channel or its connection
+	publisher      PublishFunc      //publish the messages through the middlewares of the pool
+}
+
+//ChannelNumber get the number of the channel amqp in its connection, which change when the channel is renewed, 0
+//when it is unknown
+func (reusableChannel *pooledChannel) ChannelNumber() uint16 {
+	reusableChannel.mutex.Lock()
+	defer reusableChannel.mutex.Unlock()
+
+	return reusableChannel.number
 }
 
-//Release release the reusable channel in use back to pool
-func (reusableChannel *ReusableChannel) Release() {
+//Release release the reusable channel in use back to pool, failing with ErrAlreadyReleased when it was already
+//released, even when the channel was got again by other, and with ErrPoolClosed when the pool was closed
+func (reusableChannel *ReusableChannel) Release() error {
+	reusableChannel.mutex.Lock()
+	if reusableChannel.released || reusableChannel.lease != reusableChannel.pooledChannel.lease {
+		reusableChannel.mutex.Unlock()
+		return ErrAlreadyReleased
+	}
 	reusableChannel.released = true
+	reusableChannel.mutex.Unlock()
+
+	select {
+	case <-reusableChannel.poolDone:
+		return ErrPoolClosed
+	default:
+	}
 
-	reusableChannel.channelRelease <- reusableChannel.ID
+	select {
+	case reusableChannel.channelRelease <- reusableChannel.ID:
+		return nil
+	case <-reusableChannel.poolDone:
+		return ErrPoolClosed
+	}
 }
 
 //isReleased encapsulate the verification if the reusable channel was released
 func (reusableChannel *ReusableChannel) isReleased() error {
-	if reusableChannel.released {
+	reusableChannel.mutex.Lock()
+	defer reusableChannel.mutex.Unlock()
+
+	if reusableChannel.released || reusableChannel.lease != reusableChannel.pooledChannel.lease {
 		return ErrUseReleaseChannel
 	}
 
 	return nil
 }
+
+//lend hand out the channel in a new get, getting the ReusableChannel of the get
+func (reusableChannel *pooledChannel) lend() *ReusableChannel {
+	reusableChannel.mutex.Lock()
+	defer reusableChannel.mutex.Unlock()
+
+	reusableChannel.released = false
+	reusableChannel.lease++
+
+	return &ReusableChannel{pooledChannel: reusableChannel, lease: reusableChannel.lease}
+}
+
+//isStale indicates if the channel was closed by the broker or belongs to a connection that was lost, with the
+//exception of the broker that closed the channel, if any
+func (reusableChannel *pooledChannel) isStale(generation int) (bool, *amqp.Error) {
+	select {
+	case err := <-reusableChannel.channelClose:
+		return true, err
+	default:
+		reusableChannel.mutex.Lock()
+		broken := reusableChannel.broken
+		reusableChannel.mutex.Unlock()
+
+		return broken != nil || reusableChannel.generation != generation, broken
+	}
+}
+
+//inspect mark the channel how broken when the error of an operation closed it or its connection, to be renewed when
+//got again of pool
+func (reusableChannel *pooledChannel) inspect(err error) error {
+	var amqpErr *amqp.Error
+	if ScopeOf(err) != ScopeNone && errors.As(err, &amqpErr) {
+		reusableChannel.mutex.Lock()
+		reusableChannel.broken = amqpErr
+		reusableChannel.mutex.Unlock()
+	}
+
+	return err
+}
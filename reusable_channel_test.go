@@ -1,19 +1,19 @@
-package amqppool
+package amqppool_test
 
 import (
 	"errors"
-	"log"
-	"os"
 	"testing"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/gmarcial/amqppool/amqppooltest"
 )
 
-func TestShouldReleaseAReusableChannel(t *testing.T){
+func TestShouldReleaseAReusableChannel(t *testing.T) {
 	//Arrange
-	connectionString := os.Getenv("AMQP_CONNECTION")
+	broker := amqppooltest.NewBroker()
 	maxChannels := 10
-	logger := log.New(os.Stdout, "", log.LstdFlags)
 
-	pool, _ := NewPool(connectionString, maxChannels, logger)
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
 	defer pool.Close()
 
 	reusableChannel, _ := pool.GetReusableChannel()
@@ -22,18 +22,17 @@ func TestShouldReleaseAReusableChannel(t *testing.T){
 	reusableChannel.Release()
 
 	//Assert
-	if reusableChannel.released != true {
+	if !reusableChannel.IsReleased() {
 		t.Error("The reusable channel don't was released")
 	}
 }
 
-func TestShouldReturnErrorWhenTryingUseAReleasedChannel(t *testing.T){
+func TestShouldReturnErrorWhenTryingUseAReleasedChannel(t *testing.T) {
 	//Arrange
-	connectionString := os.Getenv("AMQP_CONNECTION")
+	broker := amqppooltest.NewBroker()
 	maxChannels := 10
-	logger := log.New(os.Stdout, "", log.LstdFlags)
 
-	pool, _ := NewPool(connectionString, maxChannels, logger)
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
 	defer pool.Close()
 
 	reusableChannel, _ := pool.GetReusableChannel()
@@ -47,11 +46,95 @@ func TestShouldReturnErrorWhenTryingUseAReleasedChannel(t *testing.T){
 		t.Error("Don't returned a error in to use a channel released")
 	}
 
-	if !errors.Is(err, ErrUseReleaseChannel){
+	if !errors.Is(err, amqppool.ErrUseReleaseChannel) {
 		t.Error("The type of error returned is different of expected")
 	}
 
-	if reusableChannel.released != true {
+	if !reusableChannel.IsReleased() {
+		t.Error("The reusable channel don't was released")
+	}
+}
+
+func TestShouldReturnErrorWhenReleaseAReusableChannelTwice(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	reusableChannel, _ := pool.GetReusableChannel()
+	_ = reusableChannel.Release()
+
+	//Action
+	err := reusableChannel.Release()
+
+	//Assert
+	if !errors.Is(err, amqppool.ErrAlreadyReleased) {
+		t.Errorf("The error returned is different of expected: %v", err)
+	}
+
+	eventually(t, func() bool { return pool.LenChannelsReleased() == maxChannels }, "The reusable channel was not released")
+	if stats := pool.Stats(); stats.InUse != 0 || stats.Channels != maxChannels {
+		t.Errorf("The stores of channels were corrupted: %+v", stats)
+	}
+}
+
+func TestShouldKeepInUseTheChannelGotAgainWhenTheOldHolderReleaseIt(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	stale, _ := pool.GetReusableChannel()
+	_ = stale.Release()
+
+	current := make(chan *amqppool.ReusableChannel)
+	go func() {
+		reusableChannel, _ := pool.GetReusableChannel()
+		current <- reusableChannel
+	}()
+	reusableChannel := <-current
+	defer reusableChannel.Release()
+
+	//Action
+	err := stale.Release()
+	_, _, useErr := stale.Get("queue", false)
+
+	//Assert
+	if !errors.Is(err, amqppool.ErrAlreadyReleased) || !errors.Is(useErr, amqppool.ErrUseReleaseChannel) {
+		t.Errorf("The old holder released or used the channel got again: %v, %v", err, useErr)
+	}
+
+	if reusableChannel.ID != stale.ID || reusableChannel.IsReleased() {
+		t.Error("The channel got again is not in use")
+	}
+
+	if stats := pool.Stats(); stats.InUse != 1 || pool.LenChannelsReleased() != 0 {
+		t.Errorf("The stores of channels were corrupted: %+v", stats)
+	}
+}
+
+func TestShouldReturnErrorWhenReleaseAReusableChannelAfterClose(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	reusableChannel, _ := pool.GetReusableChannel()
+	_ = pool.Close()
+
+	//Action
+	err := reusableChannel.Release()
+
+	//Assert
+	if !errors.Is(err, amqppool.ErrPoolClosed) {
+		t.Errorf("The error returned is different of expected: %v", err)
+	}
+
+	if !reusableChannel.IsReleased() {
 		t.Error("The reusable channel don't was released")
 	}
 }
@@ -0,0 +1,297 @@
+package amqppool
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+//DirectReplyTo the pseudo-queue of RabbitMQ to receive the replies in the channel which published the requests,
+//without declare a queue of replies
+const DirectReplyTo = "amq.rabbitmq.reply-to"
+
+//RPCClient represents a client of request/reply over a pool, publishing the requests and consuming the replies by
+//direct reply-to in a reusable channel dedicated, got of the pool in the first call and renewed when is closed
+type RPCClient struct {
+	pool         *Pool                 //the pool of the channel of the replies
+	prefix       string                //the prefix of the correlation ids, unique by client
+	mutex        sync.RWMutex          //guard the channel of the replies, held for write to replace it
+	replies      *ReusableChannel      //the reusable channel of the replies, nil until the next call
+	closed       bool                  //indicates when the client was closed
+	done         chan struct{}         //a go channel closed when the client is closed
+	pendingMutex sync.Mutex            //guard the calls waiting reply
+	pending      map[string]chan reply //the calls waiting reply by correlation id
+	sequence     uint64                //the sequence of the correlation ids
+}
+
+//reply represents the reply of a call or the error which failed it
+type reply struct {
+	delivery amqp.Delivery
+	err      error
+}
+
+//NewRPCClient create a new RPCClient over the pool
+func NewRPCClient(pool *Pool) (*RPCClient, error) {
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("failed in generate the prefix of the correlation ids: %w", err)
+	}
+
+	client := &RPCClient{
+		pool:    pool,
+		prefix:  hex.EncodeToString(random),
+		done:    make(chan struct{}),
+		pending: make(map[string]chan reply),
+	}
+
+	return client, nil
+}
+
+//Call publish a request and wait its reply until the context is done. The replies of errors of a RPCServer are
+//returned with a RPCError. The request is published mandatory, with ReplyTo to direct reply-to and a CorrelationId
+//generated when it don't have one. When the context has a deadline and the request don't have an Expiration, it
+//expire with the deadline. The request not routed fail with a ReturnedError and the calls waiting reply fail with a
+//ChannelClosedError when the channel or the connection is closed, or the consumer of the replies is cancelled by the
+//broker
+func (client *RPCClient) Call(ctx context.Context, exchange, key string, request amqp.Publishing) (amqp.Delivery, error) {
+	//a request expired would be rejected by the broker, closing the channel of the replies of all calls
+	if err := ctx.Err(); err != nil {
+		return amqp.Delivery{}, err
+	}
+
+	replies, err := client.channel(ctx)
+	if err != nil {
+		return amqp.Delivery{}, err
+	}
+
+	if request.CorrelationId == "" {
+		client.pendingMutex.Lock()
+		client.sequence++
+		request.CorrelationId = client.prefix + "-" + strconv.FormatUint(client.sequence, 10)
+		client.pendingMutex.Unlock()
+	}
+	request.ReplyTo = DirectReplyTo
+	if deadline, ok := ctx.Deadline(); ok && request.Expiration == "" {
+		expiration := int64(time.Until(deadline)/time.Millisecond) + 1
+		if expiration < 1 {
+			expiration = 1
+		}
+		request.Expiration = strconv.FormatInt(expiration, 10)
+	}
+
+	result, err := client.await(request.CorrelationId)
+	if err != nil {
+		return amqp.Delivery{}, err
+	}
+	defer client.forget(request.CorrelationId)
+
+	if err := replies.PublishContext(ctx, exchange, key, true, false, request); err != nil {
+		select {
+		case <-client.done:
+			return amqp.Delivery{}, ErrRPCClientClosed
+		default:
+			return amqp.Delivery{}, err
+		}
+	}
+
+	select {
+	case reply := <-result:
+		return reply.delivery, reply.err
+	case <-ctx.Done():
+		return amqp.Delivery{}, fmt.Errorf("failed in wait the reply of %v: %w", request.CorrelationId, ctx.Err())
+	}
+}
+
+//channel get the reusable channel of the replies, getting a new of the pool when it don't have one. The lock isn't
+//held after, so a publish blocked don't block Close, which fail the call releasing the channel
+func (client *RPCClient) channel(ctx context.Context) (*ReusableChannel, error) {
+	client.mutex.RLock()
+	defer client.mutex.RUnlock()
+
+	for client.replies == nil && !client.closed {
+		client.mutex.RUnlock()
+		err := client.open(ctx)
+		client.mutex.RLock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if client.closed {
+		return nil, ErrRPCClientClosed
+	}
+
+	return client.replies, nil
+}
+
+//open get a reusable channel of the pool and start to consume the replies by direct reply-to
+func (client *RPCClient) open(ctx context.Context) error {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if client.replies != nil || client.closed {
+		return nil
+	}
+
+	replies, err := client.pool.GetReusableChannelContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	closes, _ := replies.NotifyClose(make(chan *amqp.Error, 1))
+	cancels, _ := replies.NotifyCancel(make(chan string, 1))
+	returns, _ := replies.NotifyReturn(make(chan amqp.Return, 1))
+	deliveries, err := replies.Consume(DirectReplyTo, client.consumerTag(), true, true, false, false, nil)
+	if err != nil {
+		_ = client.discard(replies)
+		return fmt.Errorf("failed in consume the replies by direct reply-to: %w", err)
+	}
+
+	client.replies = replies
+	go client.listen(replies, deliveries, returns, closes, cancels)
+
+	return nil
+}
+
+//consumerTag get the tag of the consumer of the replies
+func (client *RPCClient) consumerTag() string {
+	return "amqppool-rpc-" + client.prefix
+}
+
+//discard release the channel of the replies closing it, instead of return it to the pool, because the listeners of
+//its closes, cancels and returns would block the channel when nobody read them
+func (client *RPCClient) discard(replies *ReusableChannel) error {
+	_ = client.pool.CloseReusableChannel(replies.ID)
+	return replies.Release()
+}
+
+//listen forward the replies and the requests returned to the calls waiting them, until the consumer stop by the
+//close of the channel or the cancel of the broker, then discard the channel of the replies and fail the calls still
+//waiting
+func (client *RPCClient) listen(replies *ReusableChannel, deliveries <-chan amqp.Delivery, returns chan amqp.Return, closes chan *amqp.Error, cancels chan string) {
+	for deliveries != nil {
+		select {
+		case delivery, open := <-deliveries:
+			if !open {
+				deliveries = nil
+				continue
+			}
+			client.resolve(delivery.CorrelationId, reply{delivery: delivery, err: replyError(delivery)})
+		case returned, open := <-returns:
+			if !open {
+				returns = nil
+				continue
+			}
+			client.resolve(returned.CorrelationId, reply{err: &ReturnedError{Return: returned}})
+		}
+	}
+
+	var cause error
+	select {
+	case closeErr := <-closes:
+		if closeErr != nil {
+			cause = closeErr
+		}
+	case tag, cancelled := <-cancels:
+		if cancelled {
+			cause = fmt.Errorf("the consumer %v of the replies was cancelled by the broker", tag)
+		} else if closeErr := <-closes; closeErr != nil {
+			cause = closeErr
+		}
+	case <-client.done:
+		return
+	}
+
+	client.mutex.Lock()
+	if client.replies == replies {
+		client.replies = nil
+		_ = client.discard(replies)
+	}
+	client.mutex.Unlock()
+
+	client.failPending(&ChannelClosedError{ID: replies.ID, Err: cause})
+}
+
+//await register a call waiting the reply of a correlation id
+func (client *RPCClient) await(correlationID string) (chan reply, error) {
+	client.pendingMutex.Lock()
+	defer client.pendingMutex.Unlock()
+
+	if _, exist := client.pending[correlationID]; exist {
+		return nil, fmt.Errorf("failed in call, already there is a call waiting the reply of %v", correlationID)
+	}
+
+	result := make(chan reply, 1)
+	client.pending[correlationID] = result
+
+	return result, nil
+}
+
+//forget remove a call of the calls waiting reply
+func (client *RPCClient) forget(correlationID string) {
+	client.pendingMutex.Lock()
+	defer client.pendingMutex.Unlock()
+
+	delete(client.pending, correlationID)
+}
+
+//resolve send the reply to the call waiting it, discarding the replies of calls which already gave up
+func (client *RPCClient) resolve(correlationID string, resolved reply) {
+	client.pendingMutex.Lock()
+	defer client.pendingMutex.Unlock()
+
+	if result, exist := client.pending[correlationID]; exist {
+		delete(client.pending, correlationID)
+		result <- resolved
+	}
+}
+
+//failPending fail all calls waiting reply with the error
+func (client *RPCClient) failPending(err error) {
+	client.pendingMutex.Lock()
+	defer client.pendingMutex.Unlock()
+
+	for correlationID, result := range client.pending {
+		delete(client.pending, correlationID)
+		result <- reply{err: err}
+	}
+}
+
+//Close stop to consume the replies, close the channel of the replies and fail the calls waiting reply with
+//ErrRPCClientClosed
+func (client *RPCClient) Close() error {
+	client.mutex.Lock()
+	if client.closed {
+		client.mutex.Unlock()
+		return ErrRPCClientClosed
+	}
+	client.closed = true
+	close(client.done)
+	replies := client.replies
+	client.replies = nil
+	client.mutex.Unlock()
+
+	client.failPending(ErrRPCClientClosed)
+	if replies == nil {
+		return nil
+	}
+
+	cancelErr := replies.Cancel(client.consumerTag(), false)
+	if err := client.discard(replies); err != nil && !errors.Is(err, ErrPoolClosed) {
+		return err
+	}
+
+	//the consumer of a channel already closed was cancelled with it
+	if ScopeOf(cancelErr) != ScopeNone {
+		return nil
+	}
+
+	return cancelErr
+}
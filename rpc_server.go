@@ -0,0 +1,138 @@
+package amqppool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+//The headers of the replies of errors of a RPCServer, converted back to a RPCError by the RPCClient
+const (
+	RPCErrorHeader     = "x-rpc-error"      //the message of the error
+	RPCErrorCodeHeader = "x-rpc-error-code" //the code of the error
+)
+
+//The codes of the errors replied by a RPCServer, besides the codes of the RPCError returned by the handlers
+const (
+	RPCErrorInternal      = "internal"       //the handler returned an error which is not a RPCError, logged by the server
+	RPCErrorUnknownMethod = "unknown_method" //there is no handler to the routing key or type of the request
+)
+
+//RPCHandler handle a request of a RPCServer, returning the reply or an error replied in the headers. The reply is
+//published through the publish middlewares of the pool
+type RPCHandler func(ctx context.Context, request amqp.Delivery) (amqp.Publishing, error)
+
+//RPCServer represents a server of request/reply over a pool, consuming the requests of a queue in a reusable channel
+//and dispatching them to the handlers registered by the type of the request or, when it don't have one, by its
+//routing key
+type RPCServer struct {
+	pool        *Pool                 //the pool of the channel of the requests
+	queue       string                //the queue of the requests
+	concurrency int                   //the maximum of requests handled at same time
+	mutex       sync.RWMutex          //guard the handlers
+	handlers    map[string]RPCHandler //the handlers by type or routing key
+}
+
+//NewRPCServer create a new RPCServer of the requests of a queue, handling until concurrency requests at same time
+func NewRPCServer(pool *Pool, queue string, concurrency int) *RPCServer {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &RPCServer{
+		pool:        pool,
+		queue:       queue,
+		concurrency: concurrency,
+		handlers:    make(map[string]RPCHandler),
+	}
+}
+
+//Handle register the handler of the requests of a type or, when they don't have one, of a routing key
+func (server *RPCServer) Handle(method string, handler RPCHandler) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.handlers[method] = handler
+}
+
+//Serve consume and handle the requests until the context is done, consuming again in other reusable channel when
+//the channel or the connection is closed. It returns nil when the context is done, after the requests in progress
+//are replied, or the error which can't be retried, e.g. of a queue not found. The handlers receive the values of the
+//context, but not its cancel, so the requests in progress are replied when it is done
+func (server *RPCServer) Serve(ctx context.Context) error {
+	return server.pool.consume(ctx, server.queue, server.concurrency, server.handle)
+}
+
+//handle dispatch a request to its handler and publish the reply to the ReplyTo of the request, with its
+//CorrelationId, acknowledging it after
+func (server *RPCServer) handle(ctx context.Context, requests *ReusableChannel, request amqp.Delivery) {
+	method := request.Type
+	if method == "" {
+		method = request.RoutingKey
+	}
+
+	server.mutex.RLock()
+	handler, exist := server.handlers[method]
+	server.mutex.RUnlock()
+
+	var response amqp.Publishing
+	var err error
+	if exist {
+		response, err = handler(ctx, request)
+	} else {
+		err = &RPCError{Code: RPCErrorUnknownMethod, Message: fmt.Sprintf("there is no handler of %q", method)}
+	}
+
+	if err != nil {
+		if !errors.As(err, new(*RPCError)) {
+			server.pool.logger.Warn("failed to handle a request of rpc", "queue", server.queue, "method", method,
+				"correlation_id", request.CorrelationId, "error", err.Error())
+		}
+		response = errorReply(err)
+	}
+
+	if request.ReplyTo != "" {
+		response.CorrelationId = request.CorrelationId
+		if err := requests.PublishContext(ctx, "", request.ReplyTo, false, false, response); err != nil {
+			server.pool.logger.Warn("failed to publish the reply of a rpc, requeueing the request", "queue", server.queue,
+				"correlation_id", request.CorrelationId, "error", err.Error())
+			_ = requests.Nack(request.DeliveryTag, false, true)
+			return
+		}
+	}
+
+	if err := requests.Ack(request.DeliveryTag, false); err != nil {
+		server.pool.logger.Warn("failed to acknowledge a request of rpc", "queue", server.queue,
+			"correlation_id", request.CorrelationId, "error", err.Error())
+	}
+}
+
+//errorReply create the reply of an error of a handler, the errors which are not a RPCError are replied with a
+//generic message to don't expose the details of the server
+func errorReply(err error) amqp.Publishing {
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		rpcErr = &RPCError{Code: RPCErrorInternal, Message: "the server failed to handle the request"}
+	}
+
+	return amqp.Publishing{
+		Headers: amqp.Table{
+			RPCErrorHeader:     rpcErr.Message,
+			RPCErrorCodeHeader: rpcErr.Code,
+		},
+	}
+}
+
+//replyError get the RPCError of a reply of error, nil when the reply is not of an error
+func replyError(reply amqp.Delivery) error {
+	message, isError := reply.Headers[RPCErrorHeader].(string)
+	if !isError {
+		return nil
+	}
+
+	code, _ := reply.Headers[RPCErrorCodeHeader].(string)
+	return &RPCError{Code: code, Message: message}
+}
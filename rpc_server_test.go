@@ -0,0 +1,308 @@
+package amqppool_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/gmarcial/amqppool/amqppooltest"
+	"github.com/streadway/amqp"
+)
+
+//serve declare the queue of the requests and serve them until the test end
+func serve(t *testing.T, pool *amqppool.Pool, server *amqppool.RPCServer, queue string) {
+	declarer, _ := pool.GetReusableChannel()
+	_, _ = declarer.QueueDeclare(queue, false, false, false, false, nil)
+	_ = declarer.Release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Serve(ctx)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		if err := <-stopped; err != nil {
+			t.Errorf("Occurred a error to serve the requests: %v", err.Error())
+		}
+	})
+}
+
+//reply create a handler which reply the body
+func reply(body string) amqppool.RPCHandler {
+	return func(ctx context.Context, request amqp.Delivery) (amqp.Publishing, error) {
+		return amqp.Publishing{Body: []byte(body)}, nil
+	}
+}
+
+func TestShouldDispatchTheRequestsByTypeOrRoutingKey(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	server := amqppool.NewRPCServer(pool, "rpc", 4)
+	server.Handle("orders.create", reply("created"))
+	server.Handle("rpc", reply("routed"))
+	serve(t, pool, server, "rpc")
+
+	client, _ := amqppool.NewRPCClient(pool)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	//Action
+	byType, typeErr := client.Call(ctx, "", "rpc", amqp.Publishing{Type: "orders.create"})
+	byKey, keyErr := client.Call(ctx, "", "rpc", amqp.Publishing{})
+
+	//Assert
+	if typeErr != nil || string(byType.Body) != "created" {
+		t.Errorf("The request was not dispatched by its type: %q, %v", byType.Body, typeErr)
+	}
+
+	if keyErr != nil || string(byKey.Body) != "routed" {
+		t.Errorf("The request was not dispatched by its routing key: %q, %v", byKey.Body, keyErr)
+	}
+}
+
+func TestShouldReplyTheErrorsOfTheHandlersToTheClient(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	server := amqppool.NewRPCServer(pool, "rpc", 1)
+	server.Handle("orders.create", func(ctx context.Context, request amqp.Delivery) (amqp.Publishing, error) {
+		return amqp.Publishing{}, &amqppool.RPCError{Code: "invalid_order", Message: "the order don't have items"}
+	})
+	server.Handle("orders.cancel", func(ctx context.Context, request amqp.Delivery) (amqp.Publishing, error) {
+		return amqp.Publishing{}, errors.New("database unavailable")
+	})
+	serve(t, pool, server, "rpc")
+
+	client, _ := amqppool.NewRPCClient(pool)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	//Action
+	_, invalidErr := client.Call(ctx, "", "rpc", amqp.Publishing{Type: "orders.create"})
+	_, internalErr := client.Call(ctx, "", "rpc", amqp.Publishing{Type: "orders.cancel"})
+	_, unknownErr := client.Call(ctx, "", "rpc", amqp.Publishing{Type: "orders.delete"})
+
+	//Assert
+	expected := map[string]error{"invalid_order": invalidErr, amqppool.RPCErrorInternal: internalErr,
+		amqppool.RPCErrorUnknownMethod: unknownErr}
+	for code, err := range expected {
+		var rpcErr *amqppool.RPCError
+		if !errors.As(err, &rpcErr) || rpcErr.Code != code || !errors.Is(err, amqppool.ErrRPC) {
+			t.Errorf("The error replied is different of expected %v: %v", code, err)
+		}
+	}
+
+	if !errors.As(internalErr, new(*amqppool.RPCError)) || strings.Contains(internalErr.Error(), "database unavailable") {
+		t.Errorf("The details of the internal error were replied: %v", internalErr)
+	}
+}
+
+func TestShouldServeAgainWhenTheConnectionIsClosed(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial),
+		amqppool.WithReconnectPolicy(0, time.Millisecond))
+	defer pool.Close()
+
+	server := amqppool.NewRPCServer(pool, "rpc", 1)
+	server.Handle("rpc", reply("pong"))
+	serve(t, pool, server, "rpc")
+
+	client, _ := amqppool.NewRPCClient(pool)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, _ = client.Call(ctx, "", "rpc", amqp.Publishing{})
+
+	//Action
+	broker.KillConnections(amqp.ConnectionForced, "broker forced connection closure with reason 'shutdown'")
+	eventually(t, func() bool { return pool.Generation() == 1 }, "The pool don't reconnected with the broker")
+
+	declarer, _ := pool.GetReusableChannel()
+	_, _ = declarer.QueueDeclare("rpc", false, false, false, false, nil)
+	_ = declarer.Release()
+
+	var delivery amqp.Delivery
+	var err error
+	eventually(t, func() bool {
+		attempt, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		delivery, err = client.Call(attempt, "", "rpc", amqp.Publishing{})
+		return err == nil
+	}, "The server don't consumed again the requests")
+
+	//Assert
+	if string(delivery.Body) != "pong" {
+		t.Errorf("The reply after the reconnection is inconsistent: %q", delivery.Body)
+	}
+}
+
+func TestShouldStopToServeWhenTheQueueIsNotFound(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	server := amqppool.NewRPCServer(pool, "rpc.missing", 1)
+
+	//Action
+	err := server.Serve(context.Background())
+
+	//Assert
+	if !amqppool.IsNotFound(err) {
+		t.Errorf("The error returned is different of expected: %v", err)
+	}
+}
+
+func TestShouldPublishTheReplyAfterTheConnectionIsUnblocked(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	declarer, _ := pool.GetReusableChannel()
+	_, _ = declarer.QueueDeclare("rpc", false, false, false, false, nil)
+	_, _ = declarer.QueueDeclare("replies", false, false, false, false, nil)
+	_ = declarer.Publish("", "rpc", false, false, amqp.Publishing{ReplyTo: "replies", CorrelationId: "1"})
+	_ = declarer.Release()
+
+	broker.Block("low on memory")
+	eventually(t, pool.IsBlocked, "The pool was not blocked")
+
+	handled := make(chan struct{}, 1)
+	server := amqppool.NewRPCServer(pool, "rpc", 1)
+	server.Handle("rpc", func(ctx context.Context, request amqp.Delivery) (amqp.Publishing, error) {
+		handled <- struct{}{}
+		return amqp.Publishing{Body: []byte("pong")}, nil
+	})
+	serve(t, pool, server, "rpc")
+	<-handled
+
+	//Action
+	broker.Unblock()
+
+	//Assert
+	eventually(t, func() bool { return broker.MessageCount("replies") == 1 }, "The reply was not published")
+	eventually(t, func() bool { return broker.MessageCount("rpc") == 0 }, "The request was not acknowledged")
+}
+
+func TestShouldPublishTheRepliesThroughThePublishMiddlewares(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial),
+		amqppool.WithPublishMiddleware(amqppool.TransformPublishing(func(msg amqp.Publishing) (amqp.Publishing, error) {
+			msg.Body = append([]byte("sealed:"), msg.Body...)
+			return msg, nil
+		})))
+	defer pool.Close()
+
+	var request []byte
+	server := amqppool.NewRPCServer(pool, "rpc", 1)
+	server.Handle("rpc", func(ctx context.Context, delivery amqp.Delivery) (amqp.Publishing, error) {
+		request = delivery.Body
+		return amqp.Publishing{Body: []byte("pong")}, nil
+	})
+	serve(t, pool, server, "rpc")
+
+	client, _ := amqppool.NewRPCClient(pool)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	//Action
+	response, err := client.Call(ctx, "", "rpc", amqp.Publishing{Body: []byte("ping")})
+
+	//Assert
+	if err != nil || string(request) != "sealed:ping" {
+		t.Errorf("The request was not published through the publish middlewares: %q, %v", request, err)
+	}
+
+	if string(response.Body) != "sealed:pong" {
+		t.Errorf("The reply was not published through the publish middlewares: %q", response.Body)
+	}
+}
+
+func TestShouldReplyTheRequestsInProgressWhenTheServeIsCancelled(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	declarer, _ := pool.GetReusableChannel()
+	_, _ = declarer.QueueDeclare("rpc", false, false, false, false, nil)
+	_ = declarer.Release()
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	server := amqppool.NewRPCServer(pool, "rpc", 1)
+	server.Handle("rpc", func(ctx context.Context, request amqp.Delivery) (amqp.Publishing, error) {
+		close(started)
+		<-finish
+		if err := ctx.Err(); err != nil {
+			return amqp.Publishing{}, err
+		}
+		return amqp.Publishing{Body: []byte("done")}, nil
+	})
+
+	serveCtx, stop := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Serve(serveCtx)
+	}()
+
+	client, _ := amqppool.NewRPCClient(pool)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	result := make(chan amqp.Delivery, 1)
+	go func() {
+		response, _ := client.Call(ctx, "", "rpc", amqp.Publishing{})
+		result <- response
+	}()
+	<-started
+
+	//Action
+	stop()
+	close(finish)
+
+	//Assert
+	if response := <-result; string(response.Body) != "done" || response.Headers[amqppool.RPCErrorHeader] != nil {
+		t.Errorf("The request in progress was not replied: %q, %v", response.Body, response.Headers)
+	}
+
+	if err := <-stopped; err != nil {
+		t.Errorf("Occurred a error to serve the requests: %v", err.Error())
+	}
+}
@@ -0,0 +1,363 @@
+package amqppool_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/gmarcial/amqppool/amqppooltest"
+	"github.com/streadway/amqp"
+)
+
+//echo reply the requests of a queue with its body in upper case, until the test end
+func echo(t *testing.T, pool *amqppool.Pool, queue string) {
+	reusableChannel, _ := pool.GetReusableChannel()
+	t.Cleanup(func() { _ = reusableChannel.Release() })
+
+	_, _ = reusableChannel.QueueDeclare(queue, false, true, false, false, nil)
+	deliveries, err := reusableChannel.Consume(queue, "", true, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("Occurred a error to consume the requests: %v", err.Error())
+	}
+
+	go func() {
+		for delivery := range deliveries {
+			_ = reusableChannel.Publish("", delivery.ReplyTo, false, false, amqp.Publishing{
+				CorrelationId: delivery.CorrelationId,
+				Body:          bytes.ToUpper(delivery.Body),
+			})
+		}
+	}()
+}
+
+func TestShouldCallAndCorrelateTheReplies(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+	echo(t, pool, "rpc")
+
+	client, _ := amqppool.NewRPCClient(pool)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	//Action
+	var wait sync.WaitGroup
+	replies := make([]string, 10)
+	errs := make([]error, 10)
+	for i := range replies {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			delivery, err := client.Call(ctx, "", "rpc", amqp.Publishing{Body: []byte(fmt.Sprintf("call %v", i))})
+			replies[i], errs[i] = string(delivery.Body), err
+		}(i)
+	}
+	wait.Wait()
+
+	//Assert
+	for i, reply := range replies {
+		if errs[i] != nil || reply != fmt.Sprintf("CALL %v", i) {
+			t.Errorf("The reply of the call %v is inconsistent: %q, %v", i, reply, errs[i])
+		}
+	}
+}
+
+func TestShouldFailTheCallWhenTheRequestIsNotRouted(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	client, _ := amqppool.NewRPCClient(pool)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	//Action
+	_, err := client.Call(ctx, "", "rpc.missing", amqp.Publishing{})
+
+	//Assert
+	if !errors.Is(err, amqppool.ErrReturned) || amqppool.ReplyCode(err) != amqp.NoRoute {
+		t.Errorf("The error returned is different of expected: %v", err)
+	}
+}
+
+func TestShouldGiveUpOfWaitTheReplyWhenTheContextIsDone(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	declarer, _ := pool.GetReusableChannel()
+	_, _ = declarer.QueueDeclare("rpc", false, false, false, false, nil)
+	_ = declarer.Release()
+
+	client, _ := amqppool.NewRPCClient(pool)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	//Action
+	_, err := client.Call(ctx, "", "rpc", amqp.Publishing{})
+
+	//Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("The error returned is different of expected: %v", err)
+	}
+
+	inspector, _ := pool.GetReusableChannel()
+	defer inspector.Release()
+	request, _, _ := inspector.Get("rpc", true)
+	if !strings.HasPrefix(request.ReplyTo, amqppool.DirectReplyTo) || request.CorrelationId == "" || request.Expiration == "" {
+		t.Errorf("The request was not published to direct reply-to: %+v", request)
+	}
+}
+
+func TestShouldFailTheCallsWaitingReplyWhenTheConnectionIsClosed(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial),
+		amqppool.WithReconnectPolicy(0, time.Millisecond))
+	defer pool.Close()
+
+	declarer, _ := pool.GetReusableChannel()
+	_, _ = declarer.QueueDeclare("rpc", false, false, false, false, nil)
+	_ = declarer.Release()
+
+	client, _ := amqppool.NewRPCClient(pool)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := client.Call(ctx, "", "rpc", amqp.Publishing{})
+		result <- err
+	}()
+	eventually(t, func() bool { return broker.MessageCount("rpc") == 1 }, "The request was not published")
+
+	//Action
+	broker.KillConnections(amqp.ConnectionForced, "broker forced connection closure with reason 'shutdown'")
+	err := <-result
+
+	//Assert
+	if !errors.Is(err, amqppool.ErrChannelClosed) || !amqppool.IsConnectionForced(err) {
+		t.Fatalf("The error returned is different of expected: %v", err)
+	}
+
+	eventually(t, func() bool { return pool.Generation() == 1 }, "The pool don't reconnected with the broker")
+	echo(t, pool, "rpc.renewed")
+	if delivery, err := client.Call(ctx, "", "rpc.renewed", amqp.Publishing{Body: []byte("renewed")}); err != nil ||
+		string(delivery.Body) != "RENEWED" {
+		t.Errorf("The call after the reconnection failed: %q, %v", delivery.Body, err)
+	}
+}
+
+func TestShouldFailTheCallsWaitingReplyWhenTheConsumerIsCancelledByTheBroker(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	declarer, _ := pool.GetReusableChannel()
+	_, _ = declarer.QueueDeclare("rpc", false, false, false, false, nil)
+	_ = declarer.Release()
+
+	client, _ := amqppool.NewRPCClient(pool)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := client.Call(ctx, "", "rpc", amqp.Publishing{})
+		result <- err
+	}()
+	eventually(t, func() bool { return broker.MessageCount("rpc") == 1 }, "The request was not published")
+
+	//Action
+	broker.CancelConsumers()
+	err := <-result
+
+	//Assert
+	if !errors.Is(err, amqppool.ErrChannelClosed) || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("The error returned is different of expected: %v", err)
+	}
+
+	echo(t, pool, "rpc.renewed")
+	if delivery, err := client.Call(ctx, "", "rpc.renewed", amqp.Publishing{Body: []byte("renewed")}); err != nil ||
+		string(delivery.Body) != "RENEWED" {
+		t.Errorf("The call after the cancel failed: %q, %v", delivery.Body, err)
+	}
+}
+
+func TestShouldCloseTheClientWhileACallIsBlocked(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	client, _ := amqppool.NewRPCClient(pool)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	broker.Block("low on memory")
+	eventually(t, pool.IsBlocked, "The pool was not blocked")
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := client.Call(ctx, "", "rpc", amqp.Publishing{})
+		result <- err
+	}()
+	eventually(t, func() bool { return pool.Stats().InUse == 1 }, "The call don't got the channel of the replies")
+	time.Sleep(20 * time.Millisecond)
+
+	//Action
+	closed := make(chan error, 1)
+	go func() {
+		closed <- client.Close()
+	}()
+
+	//Assert
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Errorf("Occurred a error to close the client: %v", err.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("The close of the client was blocked by the call")
+	}
+
+	broker.Unblock()
+	if err := <-result; !errors.Is(err, amqppool.ErrRPCClientClosed) {
+		t.Errorf("The error returned is different of expected: %v", err)
+	}
+}
+
+func TestShouldFailTheCallsAfterTheClientIsClosed(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+	echo(t, pool, "rpc")
+
+	client, _ := amqppool.NewRPCClient(pool)
+
+	//Action
+	err := client.Close()
+	_, callErr := client.Call(context.Background(), "", "rpc", amqp.Publishing{})
+
+	//Assert
+	if err != nil {
+		t.Errorf("Occurred a error to close the client: %v", err.Error())
+	}
+
+	if !errors.Is(callErr, amqppool.ErrRPCClientClosed) {
+		t.Errorf("The error returned is different of expected: %v", callErr)
+	}
+}
+
+func TestShouldNotBlockTheChannelOfTheRepliesAfterTheClientIsClosed(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	client, _ := amqppool.NewRPCClient(pool)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, _ = client.Call(ctx, "", "rpc.missing", amqp.Publishing{})
+	_ = client.Close()
+	//wait the client stop to read the returns, after its consumer be cancelled
+	time.Sleep(20 * time.Millisecond)
+
+	//Action
+	published := make(chan error, 1)
+	go func() {
+		reusableChannel, err := pool.GetReusableChannelContext(ctx)
+		if err != nil {
+			published <- err
+			return
+		}
+		defer reusableChannel.Release()
+
+		for i := 0; i < 2; i++ {
+			if err := reusableChannel.Publish("", "rpc.missing", true, false, amqp.Publishing{}); err != nil {
+				published <- err
+				return
+			}
+		}
+		published <- nil
+	}()
+
+	//Assert
+	select {
+	case err := <-published:
+		if err != nil {
+			t.Errorf("Occurred a error to publish in the channel reused: %v", err.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("The publishes of messages returned blocked the channel reused")
+	}
+}
+
+func TestShouldFailTheCallWithoutPublishWhenTheContextIsAlreadyDone(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+	echo(t, pool, "rpc")
+
+	client, _ := amqppool.NewRPCClient(pool)
+	defer client.Close()
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	//Action
+	_, err := client.Call(expired, "", "rpc", amqp.Publishing{})
+
+	//Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("The error returned is different of expected: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if delivery, err := client.Call(ctx, "", "rpc", amqp.Publishing{Body: []byte("alive")}); err != nil ||
+		string(delivery.Body) != "ALIVE" {
+		t.Errorf("The call after the call expired failed: %q, %v", delivery.Body, err)
+	}
+}
@@ -0,0 +1,104 @@
+package amqppool_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/gmarcial/amqppool/amqppooltest"
+	"github.com/streadway/amqp"
+)
+
+func TestShouldShutdownThePoolAfterTheChannelsInUseAreReleased(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	reusableChannel, _ := pool.GetReusableChannel()
+
+	_ = reusableChannel.Confirm(false)
+	confirms, _ := reusableChannel.NotifyPublish(make(chan amqp.Confirmation, 1))
+	_ = reusableChannel.Publish("", "orders", false, false, amqp.Publishing{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	//Action
+	result := make(chan error, 1)
+	go func() {
+		result <- pool.Shutdown(ctx)
+	}()
+
+	eventually(t, func() bool {
+		_, err := pool.GetReusableChannel()
+		return errors.Is(err, amqppool.ErrPoolClosed)
+	}, "The pool still hand out reusable channels while shutting down")
+
+	if broker.ConnectionCount() != 1 {
+		t.Fatal("The connection was closed before the reusable channel in use was released")
+	}
+	_ = reusableChannel.Release()
+	err := <-result
+
+	//Assert
+	if err != nil {
+		t.Fatalf("Occurred a error to shutdown the pool: %v", err.Error())
+	}
+
+	if confirmation, open := <-confirms; !open || !confirmation.Ack {
+		t.Errorf("The confirm pending was not flushed: %+v", confirmation)
+	}
+
+	if broker.ConnectionCount() != 0 {
+		t.Error("The connection was not closed by the shutdown")
+	}
+}
+
+func TestShouldShutdownThePoolWhenTheContextIsDoneWithChannelsInUse(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	reusableChannel, _ := pool.GetReusableChannel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	//Action
+	err := pool.Shutdown(ctx)
+
+	//Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("The error returned is different of expected: %v", err)
+	}
+
+	if broker.ConnectionCount() != 0 {
+		t.Error("The connection was not closed after the context was done")
+	}
+
+	if err := reusableChannel.Release(); !errors.Is(err, amqppool.ErrPoolClosed) {
+		t.Errorf("The release after the shutdown is different of expected: %v", err)
+	}
+}
+
+func TestShouldFailToCloseOrShutdownAPoolAlreadyClosed(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	_ = pool.Shutdown(context.Background())
+
+	//Action
+	closeErr := pool.Close()
+	shutdownErr := pool.Shutdown(context.Background())
+
+	//Assert
+	if !errors.Is(closeErr, amqppool.ErrPoolClosed) || !errors.Is(shutdownErr, amqppool.ErrPoolClosed) {
+		t.Errorf("The errors returned are different of expected: %v and %v", closeErr, shutdownErr)
+	}
+}
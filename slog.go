@@ -0,0 +1,43 @@
+//go:build go1.21
+// +build go1.21
+
+package amqppool
+
+import (
+	"context"
+	"log/slog"
+)
+
+//SlogLogger adapt a *slog.Logger to Logger
+type SlogLogger struct {
+	logger *slog.Logger //logger of the events
+}
+
+//NewSlogLogger create a new SlogLogger, the default logger of slog is used when logger is nil
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &SlogLogger{logger: logger}
+}
+
+//Debug implementing the Logger interface
+func (logger *SlogLogger) Debug(msg string, keysAndValues ...interface{}) {
+	logger.logger.Log(context.Background(), slog.LevelDebug, msg, keysAndValues...)
+}
+
+//Info implementing the Logger interface
+func (logger *SlogLogger) Info(msg string, keysAndValues ...interface{}) {
+	logger.logger.Log(context.Background(), slog.LevelInfo, msg, keysAndValues...)
+}
+
+//Warn implementing the Logger interface
+func (logger *SlogLogger) Warn(msg string, keysAndValues ...interface{}) {
+	logger.logger.Log(context.Background(), slog.LevelWarn, msg, keysAndValues...)
+}
+
+//Error implementing the Logger interface
+func (logger *SlogLogger) Error(msg string, keysAndValues ...interface{}) {
+	logger.logger.Log(context.Background(), slog.LevelError, msg, keysAndValues...)
+}
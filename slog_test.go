@@ -0,0 +1,35 @@
+//go:build go1.21
+// +build go1.21
+
+package amqppool
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestShouldLogTheEventsInTheSlogLogger(t *testing.T) {
+	//Arrange
+	output := &bytes.Buffer{}
+	handler := slog.NewTextHandler(output, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	})
+	logger := NewSlogLogger(slog.New(handler))
+
+	//Action
+	logger.Debug("reusable channel released", "channel_id", 1)
+	logger.Info("reconnected with the broker", "attempts", 2)
+
+	//Assert
+	if line := strings.TrimSpace(output.String()); line != `level=INFO msg="reconnected with the broker" attempts=2` {
+		t.Errorf("The events logged are inconsistent: %q", line)
+	}
+}
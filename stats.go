@@ -0,0 +1,68 @@
+package amqppool
+
+import "time"
+
+//Stats represents a snapshot of the state and the usage of a pool
+type Stats struct {
+	MaxChannels int `json:"max_channels"` //maximum quantity of channels of the pool
+
+	Channels int `json:"channels"` //quantity of reusable channels, in use and released
+	InUse    int `json:"in_use"`   //quantity of reusable channels in use
+	Idle     int `json:"idle"`     //quantity of reusable channels released
+
+	AcquireCount int64         `json:"acquire_count"`    //total of reusable channels got of the pool
+	WaitCount    int64         `json:"wait_count"`       //total of gets that waited a reusable channel be released
+	WaitDuration time.Duration `json:"wait_duration_ns"` //total time waited for reusable channels be released
+	Timeouts     int64         `json:"timeouts"`         //total of gets that gave up of wait a reusable channel
+
+	ChannelsCreated   int64 `json:"channels_created"`   //total of channels amqp opened, including the renewals
+	ChannelsClosed    int64 `json:"channels_closed"`    //total of reusable channels closed and removed of the pool
+	ChannelsDiscarded int64 `json:"channels_discarded"` //total of channels amqp discarded because were closed by the broker or its connection was lost
+
+	Reconnects int64 `json:"reconnects"` //total of reconnections with the broker
+	Generation int   `json:"generation"` //generation of the current connection, incremented on each reconnection
+
+	Connected    bool `json:"connected"`    //indicates when the pool is connected with the broker
+	Reconnecting bool `json:"reconnecting"` //indicates when the connection was lost and the pool is trying to reconnect
+	Blocked      bool `json:"blocked"`      //indicates when the broker blocked the publishes of the connection
+}
+
+//counters accumulate the usage of a pool, guarded by the lock of the pool
+type counters struct {
+	acquires          int64
+	waits             int64
+	waitDuration      time.Duration
+	timeouts          int64
+	channelsCreated   int64
+	channelsClosed    int64
+	channelsDiscarded int64
+	reconnects        int64
+}
+
+//Stats get a snapshot of the state and the usage of the pool
+func (pool *Pool) Stats() Stats {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	inUse := len(pool.channelsInUse)
+	idle := len(pool.channelsReleased)
+
+	return Stats{
+		MaxChannels:       pool.maxChannels,
+		Channels:          inUse + idle,
+		InUse:             inUse,
+		Idle:              idle,
+		AcquireCount:      pool.counters.acquires,
+		WaitCount:         pool.counters.waits,
+		WaitDuration:      pool.counters.waitDuration,
+		Timeouts:          pool.counters.timeouts,
+		ChannelsCreated:   pool.counters.channelsCreated,
+		ChannelsClosed:    pool.counters.channelsClosed,
+		ChannelsDiscarded: pool.counters.channelsDiscarded,
+		Reconnects:        pool.counters.reconnects,
+		Generation:        pool.generation,
+		Connected:         pool.connected,
+		Reconnecting:      pool.reconnecting,
+		Blocked:           pool.blockage.check() != nil,
+	}
+}
@@ -0,0 +1,112 @@
+package amqppool_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/gmarcial/amqppool/amqppooltest"
+	"github.com/streadway/amqp"
+)
+
+func TestShouldGetTheStatsOfThePool(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 3
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	first, _ := pool.GetReusableChannel()
+	second, _ := pool.GetReusableChannel()
+	second.Release()
+	eventually(t, func() bool { return pool.LenChannelsReleased() == 2 }, "The reusable channel was not released")
+	_ = pool.CloseReusableChannel(second.ID)
+
+	broker.KillConnections(amqp.ConnectionForced, "broker forced connection closure with reason 'shutdown'")
+	eventually(t, func() bool { return pool.Generation() == 1 }, "The pool don't reconnected with the broker")
+	first.Release()
+	eventually(t, func() bool { return pool.LenChannelsReleased() == 2 }, "The reusable channel was not released")
+
+	third, _ := pool.GetReusableChannel()
+	defer third.Release()
+
+	//Action
+	stats := pool.Stats()
+
+	//Assert
+	expected := amqppool.Stats{
+		MaxChannels:       maxChannels,
+		Channels:          2,
+		InUse:             1,
+		Idle:              1,
+		AcquireCount:      3,
+		ChannelsCreated:   4,
+		ChannelsClosed:    1,
+		ChannelsDiscarded: 1,
+		Reconnects:        1,
+		Generation:        1,
+		Connected:         true,
+	}
+	if stats != expected {
+		t.Errorf("The stats are inconsistent: Expected %+v and found %+v", expected, stats)
+	}
+}
+
+func TestShouldWaitAReusableChannelBeReleasedWhenAllAreInUse(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	inUse, _ := pool.GetReusableChannel()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		inUse.Release()
+	}()
+
+	//Action
+	reusableChannel, err := pool.GetReusableChannelContext(context.Background())
+
+	//Assert
+	if err != nil {
+		t.Fatalf("Occurred a error to wait a reusable channel: %v", err.Error())
+	}
+	defer reusableChannel.Release()
+
+	stats := pool.Stats()
+	if stats.WaitCount != 1 || stats.WaitDuration < 20*time.Millisecond || stats.AcquireCount != 2 {
+		t.Errorf("The wait was not accounted in the stats: %+v", stats)
+	}
+}
+
+func TestShouldGiveUpOfWaitAReusableChannelWhenTheContextIsDone(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	inUse, _ := pool.GetReusableChannel()
+	defer inUse.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	//Action
+	reusableChannel, err := pool.GetReusableChannelContext(ctx)
+
+	//Assert
+	if reusableChannel != nil || !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, amqppool.ErrAcquireTimeout) {
+		t.Fatalf("The error returned is different of expected: %v", err)
+	}
+
+	if stats := pool.Stats(); stats.WaitCount != 1 || stats.Timeouts != 1 {
+		t.Errorf("The timeout was not accounted in the stats: %+v", stats)
+	}
+}
@@ -0,0 +1,234 @@
+package amqppool
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	//DestinationQueue indicates that a binding has a queue how destination
+	DestinationQueue = "queue"
+	//DestinationExchange indicates that a binding has an exchange how destination
+	DestinationExchange = "exchange"
+)
+
+//exchangeKinds the exchange kinds built in the broker, kinds of plugins are prefixed with "x-"
+var exchangeKinds = map[string]bool{
+	amqp.ExchangeDirect:  true,
+	amqp.ExchangeFanout:  true,
+	amqp.ExchangeTopic:   true,
+	amqp.ExchangeHeaders: true,
+}
+
+//predeclaredExchanges the exchanges which the broker declare by itself in each virtual host
+var predeclaredExchanges = map[string]bool{
+	"amq.direct":  true,
+	"amq.fanout":  true,
+	"amq.topic":   true,
+	"amq.headers": true,
+	"amq.match":   true,
+}
+
+//Topology represents a declarative set of exchanges, queues and bindings to be applied in the broker
+type Topology struct {
+	Exchanges []Exchange `json:"exchanges" yaml:"exchanges"` //exchanges to declare
+	Queues    []Queue    `json:"queues" yaml:"queues"`       //queues to declare
+	Bindings  []Binding  `json:"bindings" yaml:"bindings"`   //bindings between the exchanges and queues
+}
+
+//Exchange represents the declaration of an exchange
+type Exchange struct {
+	Name       string     `json:"name" yaml:"name"`
+	Kind       string     `json:"type" yaml:"type"`
+	Durable    bool       `json:"durable" yaml:"durable"`
+	AutoDelete bool       `json:"auto_delete" yaml:"auto_delete"`
+	Internal   bool       `json:"internal" yaml:"internal"`
+	Arguments  amqp.Table `json:"arguments" yaml:"arguments"`
+}
+
+//Queue represents the declaration of a queue
+type Queue struct {
+	Name       string     `json:"name" yaml:"name"`
+	Durable    bool       `json:"durable" yaml:"durable"`
+	AutoDelete bool       `json:"auto_delete" yaml:"auto_delete"`
+	Exclusive  bool       `json:"exclusive" yaml:"exclusive"`
+	Arguments  amqp.Table `json:"arguments" yaml:"arguments"`
+}
+
+//Binding represents the declaration of a binding of an exchange source to a queue or exchange destination
+type Binding struct {
+	Source          string     `json:"source" yaml:"source"`
+	Destination     string     `json:"destination" yaml:"destination"`
+	DestinationType string     `json:"destination_type" yaml:"destination_type"` //queue or exchange, by default queue
+	RoutingKey      string     `json:"routing_key" yaml:"routing_key"`
+	Arguments       amqp.Table `json:"arguments" yaml:"arguments"`
+}
+
+//destinationType get the destination type of the binding applying the default
+func (binding Binding) destinationType() string {
+	if binding.DestinationType == "" {
+		return DestinationQueue
+	}
+
+	return binding.DestinationType
+}
+
+//Merge append the declarations of other topology, the result should be validated
+func (topology *Topology) Merge(other *Topology) {
+	topology.Exchanges = append(topology.Exchanges, other.Exchanges...)
+	topology.Queues = append(topology.Queues, other.Queues...)
+	topology.Bindings = append(topology.Bindings, other.Bindings...)
+}
+
+//Validate verify if the topology can be applied, reporting all problems found in an InvalidTopologyError
+func (topology *Topology) Validate() error {
+	var problems []string
+
+	exchanges := make(map[string]Exchange, len(topology.Exchanges))
+	for _, exchange := range topology.Exchanges {
+		problems = append(problems, validateExchange(exchange)...)
+
+		if declared, exist := exchanges[exchange.Name]; exist && !reflect.DeepEqual(declared, exchange) {
+			problems = append(problems, fmt.Sprintf("exchange %q is declared more than once with conflicting properties", exchange.Name))
+			continue
+		}
+		exchanges[exchange.Name] = exchange
+	}
+
+	queues := make(map[string]Queue, len(topology.Queues))
+	for _, queue := range topology.Queues {
+		problems = append(problems, validateQueue(queue)...)
+
+		if declared, exist := queues[queue.Name]; exist && !reflect.DeepEqual(declared, queue) {
+			problems = append(problems, fmt.Sprintf("queue %q is declared more than once with conflicting properties", queue.Name))
+			continue
+		}
+		queues[queue.Name] = queue
+	}
+
+	for _, binding := range topology.Bindings {
+		problems = append(problems, validateBinding(binding, exchanges, queues)...)
+	}
+
+	if len(problems) > 0 {
+		return &InvalidTopologyError{Problems: problems}
+	}
+
+	return nil
+}
+
+//validateExchange verify the properties of an exchange declaration
+func validateExchange(exchange Exchange) []string {
+	var problems []string
+
+	if exchange.Name == "" {
+		problems = append(problems, "exchange without name")
+	} else if strings.HasPrefix(exchange.Name, "amq.") {
+		problems = append(problems, fmt.Sprintf("exchange %q use the reserved prefix \"amq.\"", exchange.Name))
+	}
+
+	if !exchangeKinds[exchange.Kind] && !strings.HasPrefix(exchange.Kind, "x-") {
+		problems = append(problems, fmt.Sprintf("exchange %q have the unknown kind %q", exchange.Name, exchange.Kind))
+	}
+
+	if err := exchange.Arguments.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("exchange %q have invalid arguments: %v", exchange.Name, err))
+	}
+
+	return problems
+}
+
+//validateQueue verify the properties of a queue declaration
+func validateQueue(queue Queue) []string {
+	var problems []string
+
+	if queue.Name == "" {
+		problems = append(problems, "queue without name")
+	} else if strings.HasPrefix(queue.Name, "amq.") {
+		problems = append(problems, fmt.Sprintf("queue %q use the reserved prefix \"amq.\"", queue.Name))
+	}
+
+	argumentsProblems := queueArgumentsProblems(queue.Arguments)
+	argumentsProblems = append(argumentsProblems, queuePropertiesProblems(queue.Arguments, queue.Durable, queue.AutoDelete, queue.Exclusive)...)
+	for _, problem := range argumentsProblems {
+		problems = append(problems, fmt.Sprintf("queue %q: %v", queue.Name, problem))
+	}
+
+	return problems
+}
+
+//validateBinding verify if a binding reference objects declared in the topology or by the broker
+func validateBinding(binding Binding, exchanges map[string]Exchange, queues map[string]Queue) []string {
+	var problems []string
+
+	if binding.Source == "" {
+		problems = append(problems, fmt.Sprintf("binding to %q from the default exchange, which don't accept bindings", binding.Destination))
+	} else if _, exist := exchanges[binding.Source]; !exist && !predeclaredExchanges[binding.Source] {
+		problems = append(problems, fmt.Sprintf("binding to %q reference the undeclared exchange %q", binding.Destination, binding.Source))
+	}
+
+	switch binding.destinationType() {
+	case DestinationQueue:
+		if _, exist := queues[binding.Destination]; !exist {
+			problems = append(problems, fmt.Sprintf("binding from %q reference the undeclared queue %q", binding.Source, binding.Destination))
+		}
+	case DestinationExchange:
+		if _, exist := exchanges[binding.Destination]; !exist && !predeclaredExchanges[binding.Destination] {
+			problems = append(problems, fmt.Sprintf("binding from %q reference the undeclared exchange %q", binding.Source, binding.Destination))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("binding from %q have the unknown destination type %q", binding.Source, binding.DestinationType))
+	}
+
+	if err := binding.Arguments.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("binding from %q to %q have invalid arguments: %v", binding.Source, binding.Destination, err))
+	}
+
+	return problems
+}
+
+//DeclareTopology validate and declare the exchanges, queues and bindings of the topology through a reusable channel of the pool
+func (pool *Pool) DeclareTopology(topology *Topology) error {
+	if err := topology.Validate(); err != nil {
+		return err
+	}
+
+	reusableChannel, err := pool.GetReusableChannel()
+	if err != nil {
+		return err
+	}
+	defer reusableChannel.Release()
+
+	for _, exchange := range topology.Exchanges {
+		err := reusableChannel.ExchangeDeclare(exchange.Name, exchange.Kind, exchange.Durable, exchange.AutoDelete,
+			exchange.Internal, false, exchange.Arguments)
+		if err != nil {
+			return fmt.Errorf("failed to declare the exchange %q: %w", exchange.Name, err)
+		}
+	}
+
+	for _, queue := range topology.Queues {
+		_, err := reusableChannel.QueueDeclare(queue.Name, queue.Durable, queue.AutoDelete, queue.Exclusive, false, queue.Arguments)
+		if err != nil {
+			return fmt.Errorf("failed to declare the queue %q: %w", queue.Name, err)
+		}
+	}
+
+	for _, binding := range topology.Bindings {
+		var err error
+		if binding.destinationType() == DestinationExchange {
+			err = reusableChannel.ExchangeBind(binding.Destination, binding.RoutingKey, binding.Source, false, binding.Arguments)
+		} else {
+			err = reusableChannel.QueueBind(binding.Destination, binding.RoutingKey, binding.Source, false, binding.Arguments)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to bind %q to %q: %w", binding.Source, binding.Destination, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package amqppool
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/streadway/amqp"
+	"gopkg.in/yaml.v3"
+)
+
+//LoadTopologyFiles load and merge the topologies of YAML (.yaml, .yml) or JSON (.json) files, validating the result
+func LoadTopologyFiles(paths ...string) (*Topology, error) {
+	topology := &Topology{}
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var fileTopology *Topology
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			fileTopology, err = parseTopologyYAML(data)
+		case ".json":
+			fileTopology, err = parseTopologyJSON(data)
+		default:
+			err = errors.New("unknown format of topology file, expected .yaml, .yml or .json")
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the topology file %v: %w", path, err)
+		}
+
+		topology.Merge(fileTopology)
+	}
+
+	if err := topology.Validate(); err != nil {
+		return nil, err
+	}
+
+	return topology, nil
+}
+
+//LoadTopologyYAML load and validate a topology in YAML format
+func LoadTopologyYAML(data []byte) (*Topology, error) {
+	topology, err := parseTopologyYAML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := topology.Validate(); err != nil {
+		return nil, err
+	}
+
+	return topology, nil
+}
+
+//LoadTopologyJSON load and validate a topology in JSON format
+func LoadTopologyJSON(data []byte) (*Topology, error) {
+	topology, err := parseTopologyJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := topology.Validate(); err != nil {
+		return nil, err
+	}
+
+	return topology, nil
+}
+
+//parseTopologyYAML decode a topology in YAML format refusing unknown fields
+func parseTopologyYAML(data []byte) (*Topology, error) {
+	topology := &Topology{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(topology); err != nil {
+		return nil, err
+	}
+
+	normalizeTopologyArguments(topology)
+
+	return topology, nil
+}
+
+//parseTopologyJSON decode a topology in JSON format refusing unknown fields
+func parseTopologyJSON(data []byte) (*Topology, error) {
+	topology := &Topology{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+	if err := decoder.Decode(topology); err != nil {
+		return nil, err
+	}
+
+	normalizeTopologyArguments(topology)
+
+	return topology, nil
+}
+
+//normalizeTopologyArguments convert the decoded arguments to types that can be encoded in an amqp table
+func normalizeTopologyArguments(topology *Topology) {
+	for i := range topology.Exchanges {
+		topology.Exchanges[i].Arguments = normalizeTable(topology.Exchanges[i].Arguments)
+	}
+
+	for i := range topology.Queues {
+		topology.Queues[i].Arguments = normalizeTable(topology.Queues[i].Arguments)
+	}
+
+	for i := range topology.Bindings {
+		topology.Bindings[i].Arguments = normalizeTable(topology.Bindings[i].Arguments)
+	}
+}
+
+//normalizeTable normalize each value of a table
+func normalizeTable(table map[string]interface{}) amqp.Table {
+	if table == nil {
+		return nil
+	}
+
+	normalized := make(amqp.Table, len(table))
+	for key, value := range table {
+		normalized[key] = normalizeValue(value)
+	}
+
+	return normalized
+}
+
+//normalizeValue convert numbers to int64 when are integers and nested maps to amqp tables,
+//the values that still are unsupported are reported by the validation of the table
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return int64(v)
+		}
+		return v
+	case json.Number:
+		if integer, err := v.Int64(); err == nil {
+			return integer
+		}
+		if float, err := v.Float64(); err == nil {
+			return float
+		}
+		return v.String()
+	case map[string]interface{}:
+		return normalizeTable(v)
+	case amqp.Table:
+		return normalizeTable(v)
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, item := range v {
+			normalized[i] = normalizeValue(item)
+		}
+		return normalized
+	default:
+		return v
+	}
+}
@@ -0,0 +1,102 @@
+package amqppool
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestShouldLoadATopologyOfYAMLAndJSONFiles(t *testing.T) {
+	//Arrange
+	paths := []string{"testdata/topology.yaml", "testdata/topology.json"}
+
+	//Action
+	topology, err := LoadTopologyFiles(paths...)
+
+	//Assert
+	if err != nil {
+		t.Fatalf("Occurred a error to load the topology files: %v", err.Error())
+	}
+
+	if len(topology.Exchanges) != 3 || len(topology.Queues) != 3 || len(topology.Bindings) != 4 {
+		t.Errorf("The quantity of declarations loaded is inconsistent: Expected 3 exchanges, 3 queues and 4 bindings and found %v, %v and %v",
+			len(topology.Exchanges), len(topology.Queues), len(topology.Bindings))
+	}
+
+	if maxLength, _ := topology.Queues[0].Arguments["x-max-length"].(int64); maxLength != 1000 {
+		t.Errorf("The argument of YAML was not converted to int64: found %T", topology.Queues[0].Arguments["x-max-length"])
+	}
+
+	if ttl, _ := topology.Queues[2].Arguments["x-message-ttl"].(int64); ttl != 60000 {
+		t.Errorf("The argument of JSON was not converted to int64: found %T", topology.Queues[2].Arguments["x-message-ttl"])
+	}
+}
+
+func TestShouldReturnErrorWhenLoadATopologyWithUnknownFields(t *testing.T) {
+	//Arrange
+	data := []byte(`{"queues": [{"name": "orders", "durabel": true}]}`)
+
+	//Action
+	topology, err := LoadTopologyJSON(data)
+
+	//Assert
+	if err == nil || topology != nil {
+		t.Error("Don't occurred a error to load a topology with a unknown field")
+	}
+}
+
+func TestShouldReturnErrorWhenLoadAnInvalidTopology(t *testing.T) {
+	//Arrange
+	data := []byte(`
+exchanges:
+  - name: orders
+    type: random
+  - name: payments
+    type: direct
+    durable: true
+  - name: payments
+    type: direct
+queues:
+  - name: orders.created
+    arguments:
+      x-dead-letter-routing-key: dead
+bindings:
+  - source: orders
+    destination: orders.unknown
+  - source: shipping
+    destination: orders.created
+`)
+
+	//Action
+	topology, err := LoadTopologyYAML(data)
+
+	//Assert
+	if topology != nil {
+		t.Error("The topology was loaded same being invalid")
+	}
+
+	var invalidTopologyError *InvalidTopologyError
+	if !errors.As(err, &invalidTopologyError) {
+		t.Fatalf("The type of error returned is different of expected: %v", err)
+	}
+
+	if len(invalidTopologyError.Problems) != 5 {
+		t.Errorf("The quantity of problems is inconsistent: Expected 5 and found %v: %v",
+			len(invalidTopologyError.Problems), invalidTopologyError.Problems)
+	}
+}
+
+func TestShouldAcceptBindingsToTheExchangesPredeclaredByTheBroker(t *testing.T) {
+	//Arrange
+	topology := &Topology{
+		Queues:   []Queue{{Name: "audit", Durable: true}},
+		Bindings: []Binding{{Source: "amq.fanout", Destination: "audit"}},
+	}
+
+	//Action
+	err := topology.Validate()
+
+	//Assert
+	if err != nil {
+		t.Errorf("Occurred a error to validate the topology: %v", err.Error())
+	}
+}
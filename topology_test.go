@@ -0,0 +1,72 @@
+package amqppool_test
+
+import (
+	"testing"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/gmarcial/amqppool/amqppooltest"
+	"github.com/streadway/amqp"
+)
+
+func TestShouldDeclareATopologyAndVerifyWhichIsInSync(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	topology := &amqppool.Topology{
+		Exchanges: []amqppool.Exchange{{Name: "amqppool.verify", Kind: amqp.ExchangeTopic, AutoDelete: true}},
+		Queues:    []amqppool.Queue{{Name: "amqppool.verify", AutoDelete: true, Arguments: amqp.Table{"x-max-length": int64(10)}}},
+		Bindings:  []amqppool.Binding{{Source: "amqppool.verify", Destination: "amqppool.verify", RoutingKey: "#"}},
+	}
+	_ = pool.DeclareTopology(topology)
+
+	//Action
+	diff, err := pool.Verify(topology)
+
+	//Assert
+	if err != nil {
+		t.Fatalf("Occurred a error to verify the topology: %v", err.Error())
+	}
+
+	if !diff.InSync() {
+		t.Errorf("The topology declared was verified how out of sync: %+v", diff)
+	}
+}
+
+func TestShouldVerifyTheMissingAndMismatchedDeclarationsOfATopology(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 1
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+
+	declared := &amqppool.Topology{
+		Queues: []amqppool.Queue{{Name: "amqppool.mismatch", AutoDelete: true, Arguments: amqp.Table{"x-max-length": int64(10)}}},
+	}
+	_ = pool.DeclareTopology(declared)
+
+	expected := &amqppool.Topology{
+		Exchanges: []amqppool.Exchange{{Name: "amqppool.missing", Kind: amqp.ExchangeDirect}},
+		Queues:    []amqppool.Queue{{Name: "amqppool.mismatch", AutoDelete: true, Arguments: amqp.Table{"x-max-length": int64(20)}}},
+	}
+
+	//Action
+	diff, err := pool.Verify(expected)
+
+	//Assert
+	if err != nil {
+		t.Fatalf("Occurred a error to verify the topology: %v", err.Error())
+	}
+
+	if len(diff.MissingExchanges) != 1 || diff.MissingExchanges[0] != "amqppool.missing" {
+		t.Errorf("The missing exchanges are inconsistent: %v", diff.MissingExchanges)
+	}
+
+	if len(diff.Mismatches) != 1 || diff.Mismatches[0].Code != amqp.PreconditionFailed {
+		t.Errorf("The mismatches are inconsistent: %+v", diff.Mismatches)
+	}
+}
@@ -0,0 +1,148 @@
+package amqppool
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	//KindExchange indicates that a mismatch is of an exchange
+	KindExchange = "exchange"
+	//KindQueue indicates that a mismatch is of a queue
+	KindQueue = "queue"
+)
+
+//declarationState the state of a declaration in the broker found by a verification
+type declarationState int
+
+const (
+	declarationFound declarationState = iota
+	declarationMissing
+	declarationMismatch
+)
+
+//TopologyDiff represents the differences between a topology and the state of the broker
+type TopologyDiff struct {
+	MissingExchanges []string           //exchanges of the topology that don't exist in the broker
+	MissingQueues    []string           //queues of the topology that don't exist in the broker
+	Mismatches       []TopologyMismatch //exchanges and queues that exist with other properties or arguments
+}
+
+//TopologyMismatch represents an exchange or queue that exist in the broker but is not equivalent to the declaration
+type TopologyMismatch struct {
+	Kind   string //exchange or queue
+	Name   string //name of the exchange or queue
+	Code   int    //reply code of the broker, usually 406 PRECONDITION_FAILED
+	Reason string //reason of the broker
+}
+
+//InSync indicates if the broker have all declarations of the topology
+func (diff *TopologyDiff) InSync() bool {
+	return len(diff.MissingExchanges) == 0 && len(diff.MissingQueues) == 0 && len(diff.Mismatches) == 0
+}
+
+//Verify compare the exchanges and queues of the topology with the state of the broker without create anything.
+//
+//The existence is checked with passive declarations and, when exist, the equivalence of properties and arguments
+//is checked by a declaration with the same parameters, which the broker refuse with PRECONDITION_FAILED when differ.
+//The passive declarations of the broker ignore the properties and arguments, so the equivalence can only be checked
+//by a declaration not passive: when an exchange or queue is deleted between the two declarations, it is created
+//again by the verification. Bindings can't be inspected through amqp and are not verified, neither the equivalence
+//of exclusive queues.
+func (pool *Pool) Verify(topology *Topology) (*TopologyDiff, error) {
+	if err := topology.Validate(); err != nil {
+		return nil, err
+	}
+
+	reusableChannel, err := pool.GetReusableChannel()
+	if err != nil {
+		return nil, err
+	}
+	defer reusableChannel.Release()
+
+	diff := &TopologyDiff{}
+
+	for _, exchange := range topology.Exchanges {
+		state, err := pool.verifyDeclaration(reusableChannel, diff, KindExchange, exchange.Name, func() error {
+			return reusableChannel.ExchangeDeclarePassive(exchange.Name, exchange.Kind, exchange.Durable, exchange.AutoDelete,
+				exchange.Internal, false, exchange.Arguments)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if state == declarationMissing {
+			diff.MissingExchanges = append(diff.MissingExchanges, exchange.Name)
+		}
+
+		if state != declarationFound {
+			continue
+		}
+
+		_, err = pool.verifyDeclaration(reusableChannel, diff, KindExchange, exchange.Name, func() error {
+			return reusableChannel.ExchangeDeclare(exchange.Name, exchange.Kind, exchange.Durable, exchange.AutoDelete,
+				exchange.Internal, false, exchange.Arguments)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, queue := range topology.Queues {
+		state, err := pool.verifyDeclaration(reusableChannel, diff, KindQueue, queue.Name, func() error {
+			_, err := reusableChannel.QueueInspect(queue.Name)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if state == declarationMissing {
+			diff.MissingQueues = append(diff.MissingQueues, queue.Name)
+		}
+
+		if state != declarationFound || queue.Exclusive {
+			continue
+		}
+
+		_, err = pool.verifyDeclaration(reusableChannel, diff, KindQueue, queue.Name, func() error {
+			_, err := reusableChannel.QueueDeclare(queue.Name, queue.Durable, queue.AutoDelete, queue.Exclusive, false, queue.Arguments)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return diff, nil
+}
+
+//verifyDeclaration run a declaration and classify the exception of the broker, registering the mismatches in the diff.
+//The broker close the channel on exceptions, so the reusable channel is renewed to continue the verification.
+func (pool *Pool) verifyDeclaration(reusableChannel *ReusableChannel, diff *TopologyDiff, kind, name string, declare func() error) (declarationState, error) {
+	err := declare()
+	if err == nil {
+		return declarationFound, nil
+	}
+
+	var amqpErr *amqp.Error
+	if !errors.As(err, &amqpErr) || !amqpErr.Server {
+		return declarationMissing, fmt.Errorf("failed to verify the %v %q: %w", kind, name, err)
+	}
+
+	if err := pool.renewReusableChannel(reusableChannel.pooledChannel); err != nil {
+		return declarationMissing, fmt.Errorf("failed to renew the channel after verify the %v %q: %w", kind, name, err)
+	}
+
+	switch amqpErr.Code {
+	case amqp.NotFound:
+		return declarationMissing, nil
+	case amqp.PreconditionFailed, amqp.ResourceLocked, amqp.AccessRefused:
+		diff.Mismatches = append(diff.Mismatches, TopologyMismatch{Kind: kind, Name: name, Code: amqpErr.Code, Reason: amqpErr.Reason})
+		return declarationMismatch, nil
+	default:
+		return declarationMissing, fmt.Errorf("failed to verify the %v %q: %w", kind, name, err)
+	}
+}
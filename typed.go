@@ -0,0 +1,198 @@
+package amqppool
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/streadway/amqp"
+)
+
+//DecodeErrorHeader the header with the error of decode of the messages sent to the destination of the undecodable
+const DecodeErrorHeader = "x-decode-error"
+
+//Publish encode a value with the codec and publish it through the pool, setting the content type of the codec
+func Publish[T any](ctx context.Context, pool *Pool, codec Codec, exchange, key string, value T) error {
+	body, err := codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode the message in %v: %w", codec.ContentType(), err)
+	}
+
+	return pool.Publish(ctx, exchange, key, false, false, amqp.Publishing{ContentType: codec.ContentType(), Body: body})
+}
+
+//PublishJSON encode a value in JSON and publish it through the pool
+func PublishJSON[T any](ctx context.Context, pool *Pool, exchange, key string, value T) error {
+	return Publish(ctx, pool, JSON, exchange, key, value)
+}
+
+//MessageHandler handle a message decoded, the message is acknowledged when it returns nil and nacked otherwise,
+//requeued only when the error is retryable
+type MessageHandler[T any] func(ctx context.Context, value T, delivery amqp.Delivery) error
+
+//Consumer consume the messages of a queue decoding their body into T by the codec of its content type
+type Consumer[T any] struct {
+	pool    *Pool           //the pool of the channel of the messages
+	queue   string          //the queue of the messages
+	options consumerOptions //the options of the consumer
+}
+
+//ConsumerOption configure a Consumer in its creation
+type ConsumerOption func(consumer *consumerOptions)
+
+//consumerOptions the options of a Consumer, apart of it because the options can't be generic
+type consumerOptions struct {
+	codecs           []Codec              //the codecs of the content types accepted, the first decode the messages without one
+	concurrency      int                  //the maximum of messages handled at same time
+	undecodable      bool                 //indicates when the undecodable messages are sent to a destination instead of nacked
+	undecodableTo    string               //the exchange of destination of the undecodable messages
+	undecodableToKey string               //the routing key of destination of the undecodable messages
+	middlewares      []DeliveryMiddleware //transform the deliveries before they are decoded, in order
+}
+
+//WithCodecs replace the codecs of the content types accepted, by default JSON and Gob, the first decode the messages
+//without a content type
+func WithCodecs(codecs ...Codec) ConsumerOption {
+	return func(options *consumerOptions) {
+		options.codecs = codecs
+	}
+}
+
+//WithConcurrency configure the maximum of messages handled at same time, by default 1
+func WithConcurrency(concurrency int) ConsumerOption {
+	return func(options *consumerOptions) {
+		options.concurrency = concurrency
+	}
+}
+
+//WithDeliveryMiddleware add middlewares which transform the deliveries before they are decoded, in the order added,
+//e.g. the decompression of the package amqppoolcompress
+func WithDeliveryMiddleware(middlewares ...DeliveryMiddleware) ConsumerOption {
+	return func(options *consumerOptions) {
+		options.middlewares = append(options.middlewares, middlewares...)
+	}
+}
+
+//WithUndecodableDestination publish the undecodable messages to an exchange, with the error in the header
+//x-decode-error, acknowledging them after. By default they are nacked without requeue, to the dead letter exchange
+//of the queue if any
+func WithUndecodableDestination(exchange, key string) ConsumerOption {
+	return func(options *consumerOptions) {
+		options.undecodable = true
+		options.undecodableTo = exchange
+		options.undecodableToKey = key
+	}
+}
+
+//NewConsumer create a new Consumer of the messages of a queue
+func NewConsumer[T any](pool *Pool, queue string, options ...ConsumerOption) *Consumer[T] {
+	consumer := &Consumer[T]{
+		pool:    pool,
+		queue:   queue,
+		options: consumerOptions{codecs: []Codec{JSON, Gob}, concurrency: 1},
+	}
+
+	for _, option := range options {
+		option(&consumer.options)
+	}
+
+	if consumer.options.concurrency < 1 {
+		consumer.options.concurrency = 1
+	}
+
+	return consumer
+}
+
+//Consume consume and handle the messages until the context is done, consuming again in other reusable channel when
+//the channel or the connection is closed. It returns nil when the context is done, after the messages in progress
+//are handled, or the error which can't be retried, e.g. of a queue not found. The handlers receive the values of
+//the context, but not its cancel, so the messages in progress are handled when it is done
+func (consumer *Consumer[T]) Consume(ctx context.Context, handler MessageHandler[T]) error {
+	return consumer.pool.consume(ctx, consumer.queue, consumer.options.concurrency,
+		func(ctx context.Context, channel *ReusableChannel, delivery amqp.Delivery) {
+			consumer.handle(ctx, channel, delivery, handler)
+		})
+}
+
+//handle transform and decode a message and pass it to the handler, acknowledging or nacking it according to the
+//result, the messages which fail to be transformed or decoded are rejected how received
+func (consumer *Consumer[T]) handle(ctx context.Context, channel *ReusableChannel, delivery amqp.Delivery, handler MessageHandler[T]) {
+	received := delivery
+	for _, middleware := range consumer.options.middlewares {
+		transformed, err := middleware(delivery)
+		if err != nil {
+			consumer.reject(ctx, channel, received, err)
+			return
+		}
+		delivery = transformed
+	}
+
+	value, err := consumer.decode(delivery)
+	if err != nil {
+		consumer.reject(ctx, channel, received, err)
+		return
+	}
+
+	if err := handler(ctx, value, delivery); err != nil {
+		_ = channel.Nack(delivery.DeliveryTag, false, IsRetryable(err))
+		return
+	}
+
+	_ = channel.Ack(delivery.DeliveryTag, false)
+}
+
+//decode decode the body of a message by the codec of its content type
+func (consumer *Consumer[T]) decode(delivery amqp.Delivery) (T, error) {
+	var value T
+
+	codec, err := codecFor(consumer.options.codecs, delivery.ContentType)
+	if err != nil {
+		return value, err
+	}
+
+	if err := codec.Unmarshal(delivery.Body, &value); err != nil {
+		return value, fmt.Errorf("failed to decode the message in %v: %w", codec.ContentType(), err)
+	}
+
+	return value, nil
+}
+
+//reject send an undecodable message how received to the destination configured, without the publish middlewares,
+//or nack it without requeue
+func (consumer *Consumer[T]) reject(ctx context.Context, channel *ReusableChannel, delivery amqp.Delivery, decodeErr error) {
+	consumer.pool.logger.Warn("failed to decode a message", "queue", consumer.queue, "message_id", delivery.MessageId,
+		"content_type", delivery.ContentType, "error", decodeErr.Error())
+
+	if !consumer.options.undecodable {
+		_ = channel.Nack(delivery.DeliveryTag, false, false)
+		return
+	}
+
+	headers := amqp.Table{}
+	for name, value := range delivery.Headers {
+		headers[name] = value
+	}
+	headers[DecodeErrorHeader] = decodeErr.Error()
+
+	err := channel.forward(ctx, consumer.options.undecodableTo, consumer.options.undecodableToKey, false, false, amqp.Publishing{
+		Headers:         headers,
+		ContentType:     delivery.ContentType,
+		ContentEncoding: delivery.ContentEncoding,
+		DeliveryMode:    delivery.DeliveryMode,
+		Priority:        delivery.Priority,
+		CorrelationId:   delivery.CorrelationId,
+		ReplyTo:         delivery.ReplyTo,
+		Expiration:      delivery.Expiration,
+		MessageId:       delivery.MessageId,
+		Timestamp:       delivery.Timestamp,
+		Type:            delivery.Type,
+		UserId:          delivery.UserId,
+		AppId:           delivery.AppId,
+		Body:            delivery.Body,
+	})
+	if err != nil {
+		_ = channel.Nack(delivery.DeliveryTag, false, true)
+		return
+	}
+
+	_ = channel.Ack(delivery.DeliveryTag, false)
+}
@@ -0,0 +1,228 @@
+package amqppool_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gmarcial/amqppool"
+	"github.com/gmarcial/amqppool/amqppoolcompress"
+	"github.com/gmarcial/amqppool/amqppooltest"
+	"github.com/streadway/amqp"
+)
+
+type order struct {
+	ID    int
+	Items []string
+}
+
+//consumeOrders declare the queue of the orders and consume them until the test end
+func consumeOrders(t *testing.T, consumer *amqppool.Consumer[order], handler amqppool.MessageHandler[order]) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- consumer.Consume(ctx, handler)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		if err := <-stopped; err != nil {
+			t.Errorf("Occurred a error to consume the orders: %v", err.Error())
+		}
+	})
+}
+
+//declare declare the queues
+func declare(pool *amqppool.Pool, queues map[string]amqp.Table) {
+	declarer, _ := pool.GetReusableChannel()
+	defer declarer.Release()
+
+	for queue, args := range queues {
+		_, _ = declarer.QueueDeclare(queue, false, false, false, false, args)
+	}
+}
+
+func TestShouldPublishAndConsumeTheMessagesTyped(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+	declare(pool, map[string]amqp.Table{"orders": nil})
+
+	consumed := make(chan order, 2)
+	contentTypes := make(chan string, 2)
+	consumer := amqppool.NewConsumer[order](pool, "orders")
+	consumeOrders(t, consumer, func(ctx context.Context, value order, delivery amqp.Delivery) error {
+		consumed <- value
+		contentTypes <- delivery.ContentType
+		return nil
+	})
+
+	//Action
+	jsonErr := amqppool.PublishJSON(context.Background(), pool, "", "orders", order{ID: 1, Items: []string{"book"}})
+	gobErr := amqppool.Publish(context.Background(), pool, amqppool.Gob, "", "orders", order{ID: 2})
+
+	//Assert
+	if jsonErr != nil || gobErr != nil {
+		t.Fatalf("Occurred a error to publish the orders: %v, %v", jsonErr, gobErr)
+	}
+
+	for _, expected := range []string{"application/json", "application/x-gob"} {
+		select {
+		case value := <-consumed:
+			if contentType := <-contentTypes; contentType != expected || value.ID == 0 {
+				t.Errorf("The order consumed is inconsistent: %+v in %v", value, contentType)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("The orders was not consumed")
+		}
+	}
+
+	eventually(t, func() bool { return broker.MessageCount("orders") == 0 }, "The orders was not acknowledged")
+}
+
+func TestShouldSendTheUndecodableMessagesToTheDestination(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+	declare(pool, map[string]amqp.Table{"orders": nil, "orders.undecodable": nil})
+
+	consumer := amqppool.NewConsumer[order](pool, "orders", amqppool.WithUndecodableDestination("", "orders.undecodable"))
+	consumeOrders(t, consumer, func(ctx context.Context, value order, delivery amqp.Delivery) error {
+		return nil
+	})
+
+	//Action
+	_ = pool.Publish(context.Background(), "", "orders", false, false, amqp.Publishing{
+		ContentType: "text/plain",
+		MessageId:   "order-1",
+		Body:        []byte("order 1"),
+	})
+
+	//Assert
+	eventually(t, func() bool { return broker.MessageCount("orders.undecodable") == 1 },
+		"The undecodable message was not sent to the destination")
+
+	inspector, _ := pool.GetReusableChannel()
+	defer inspector.Release()
+	undecodable, _, _ := inspector.Get("orders.undecodable", true)
+	if undecodable.MessageId != "order-1" || string(undecodable.Body) != "order 1" ||
+		undecodable.Headers[amqppool.DecodeErrorHeader] == nil {
+		t.Errorf("The undecodable message is inconsistent: %+v", undecodable)
+	}
+
+	eventually(t, func() bool { return broker.MessageCount("orders") == 0 }, "The undecodable message was not acknowledged")
+}
+
+func TestShouldSendTheUndecodableMessagesWithoutThePublishMiddlewares(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial),
+		amqppool.WithPublishMiddleware(amqppool.TransformPublishing(func(msg amqp.Publishing) (amqp.Publishing, error) {
+			msg.Body = append([]byte("sealed:"), msg.Body...)
+			return msg, nil
+		})))
+	defer pool.Close()
+	declare(pool, map[string]amqp.Table{"orders": nil, "orders.undecodable": nil})
+
+	consumer := amqppool.NewConsumer[order](pool, "orders", amqppool.WithUndecodableDestination("", "orders.undecodable"))
+	consumeOrders(t, consumer, func(ctx context.Context, value order, delivery amqp.Delivery) error {
+		return nil
+	})
+
+	//Action
+	_ = pool.Publish(context.Background(), "", "orders", false, false, amqp.Publishing{
+		ContentType: "text/plain",
+		Body:        []byte("order 1"),
+	})
+
+	//Assert
+	eventually(t, func() bool { return broker.MessageCount("orders.undecodable") == 1 },
+		"The undecodable message was not sent to the destination")
+
+	inspector, _ := pool.GetReusableChannel()
+	defer inspector.Release()
+	undecodable, _, _ := inspector.Get("orders.undecodable", true)
+	if string(undecodable.Body) != "sealed:order 1" {
+		t.Errorf("The undecodable message was not sent how received: %q", undecodable.Body)
+	}
+}
+
+func TestShouldNackTheUndecodableMessagesWithoutDestination(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+	declare(pool, map[string]amqp.Table{
+		"orders":           {"x-dead-letter-exchange": "", "x-dead-letter-routing-key": "orders.dead"},
+		"orders.dead":      nil,
+		"orders.unhandled": nil,
+	})
+
+	consumer := amqppool.NewConsumer[order](pool, "orders", amqppool.WithCodecs(amqppool.JSON))
+	consumeOrders(t, consumer, func(ctx context.Context, value order, delivery amqp.Delivery) error {
+		return errors.New("the order is invalid")
+	})
+
+	//Action
+	_ = amqppool.Publish(context.Background(), pool, amqppool.Gob, "", "orders", order{ID: 1})
+	_ = amqppool.PublishJSON(context.Background(), pool, "", "orders", order{ID: 2})
+
+	//Assert
+	eventually(t, func() bool { return broker.MessageCount("orders.dead") == 2 },
+		"The undecodable message and the message not handled was not nacked without requeue")
+}
+
+func TestShouldTransformTheDeliveriesBeforeDecodeThem(t *testing.T) {
+	//Arrange
+	broker := amqppooltest.NewBroker()
+	maxChannels := 2
+
+	pool, _ := amqppool.NewPool(connectionString, maxChannels, amqppool.WithDialer(broker.Dial))
+	defer pool.Close()
+	declare(pool, map[string]amqp.Table{"orders": nil, "orders.undecodable": nil})
+
+	compression := amqppoolcompress.NewCompression(amqppoolcompress.Gzip, amqppoolcompress.WithThreshold(0))
+	consumed := make(chan order, 1)
+	consumer := amqppool.NewConsumer[order](pool, "orders", amqppool.WithDeliveryMiddleware(compression.Decompress),
+		amqppool.WithUndecodableDestination("", "orders.undecodable"))
+	consumeOrders(t, consumer, func(ctx context.Context, value order, delivery amqp.Delivery) error {
+		consumed <- value
+		return nil
+	})
+
+	body, _ := json.Marshal(order{ID: 1})
+	compressed, _ := compression.Compress(amqp.Publishing{ContentType: "application/json", Body: body})
+
+	//Action
+	_ = pool.Publish(context.Background(), "", "orders", false, false, compressed)
+	_ = pool.Publish(context.Background(), "", "orders", false, false, amqp.Publishing{
+		ContentType:     "application/json",
+		ContentEncoding: "br",
+		Body:            body,
+	})
+
+	//Assert
+	select {
+	case value := <-consumed:
+		if value.ID != 1 {
+			t.Errorf("The order decompressed is inconsistent: %+v", value)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("The order compressed was not consumed")
+	}
+
+	eventually(t, func() bool { return broker.MessageCount("orders.undecodable") == 1 },
+		"The message which failed to be transformed was not sent to the destination")
+}
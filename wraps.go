@@ -1,6 +1,11 @@
 package amqppool
 
-import "github.com/streadway/amqp"
+import (
+	"context"
+	"time"
+
+	"github.com/streadway/amqp"
+)
 
 //Declaration of wrappers of the methods of the channel,
 //complementing them with the behavior of the pool.
@@ -11,7 +16,7 @@ func (reusableChannel *ReusableChannel) Ack(tag uint64, multiple bool) error {
 		return err
 	}
 
-	return reusableChannel.channel.Ack(tag, multiple)
+	return reusableChannel.inspect(reusableChannel.channel.Ack(tag, multiple))
 }
 
 //Reject wrap to use in reusable channel
@@ -20,7 +25,7 @@ func (reusableChannel *ReusableChannel) Reject(tag uint64, requeue bool) error {
 		return err
 	}
 
-	return reusableChannel.channel.Reject(tag, requeue)
+	return reusableChannel.inspect(reusableChannel.channel.Reject(tag, requeue))
 }
 
 //Nack wrap to use in reusable channel
@@ -29,7 +34,7 @@ func (reusableChannel *ReusableChannel) Nack(tag uint64, multiple bool, requeue
 		return err
 	}
 
-	return reusableChannel.channel.Nack(tag, multiple, requeue)
+	return reusableChannel.inspect(reusableChannel.channel.Nack(tag, multiple, requeue))
 }
 
 //Publish wrap to use in reusable channel
@@ -38,7 +43,47 @@ func (reusableChannel *ReusableChannel) Publish(exchange, key string, mandatory,
 		return err
 	}
 
-	return reusableChannel.channel.Publish(exchange, key, mandatory, immediate, msg)
+	if err := reusableChannel.blockage.check(); err != nil {
+		return err
+	}
+
+	return reusableChannel.publisher(context.Background(), exchange, key, mandatory, immediate, msg)
+}
+
+//PublishContext publish a message, waiting while the connection is blocked by the broker until the context is done
+func (reusableChannel *ReusableChannel) PublishContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	if err := reusableChannel.isReleased(); err != nil {
+		return err
+	}
+
+	if err := reusableChannel.blockage.wait(ctx); err != nil {
+		return err
+	}
+
+	return reusableChannel.publisher(ctx, exchange, key, mandatory, immediate, msg)
+}
+
+//forward publish a message without the publish middlewares of the pool, waiting while the connection is blocked by
+//the broker until the context is done, to re-publish the undecodable messages how received
+func (reusableChannel *ReusableChannel) forward(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	if err := reusableChannel.isReleased(); err != nil {
+		return err
+	}
+
+	if err := reusableChannel.blockage.wait(ctx); err != nil {
+		return err
+	}
+
+	return reusableChannel.send(ctx, exchange, key, mandatory, immediate, msg)
+}
+
+//send publish a message in the channel, measuring its latency, the last step of the middlewares of the pool
+func (reusableChannel *pooledChannel) send(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	start := time.Now()
+	err := reusableChannel.inspect(reusableChannel.channel.Publish(exchange, key, mandatory, immediate, msg))
+	reusableChannel.observer.ObservePublish(time.Since(start), err)
+
+	return err
 }
 
 //QueueBind wrap to use in reusable channel
@@ -47,7 +92,7 @@ func (reusableChannel *ReusableChannel) QueueBind(name, key, exchange string, no
 		return err
 	}
 
-	return reusableChannel.channel.QueueBind(name, key, exchange, noWait, args)
+	return reusableChannel.inspect(reusableChannel.channel.QueueBind(name, key, exchange, noWait, args))
 }
 
 //ExchangeDeclare wrap to use in reusable channel
@@ -56,7 +101,7 @@ func (reusableChannel *ReusableChannel) ExchangeDeclare(name, kind string, durab
 		return err
 	}
 
-	return reusableChannel.channel.ExchangeDeclare(name, kind, durable, autoDelete, internal, noWait, args)
+	return reusableChannel.inspect(reusableChannel.channel.ExchangeDeclare(name, kind, durable, autoDelete, internal, noWait, args))
 }
 
 //Cancel wrap to use in reusable channel
@@ -65,7 +110,7 @@ func (reusableChannel *ReusableChannel) Cancel(consumer string, noWait bool) err
 		return err
 	}
 
-	return reusableChannel.channel.Cancel(consumer, noWait)
+	return reusableChannel.inspect(reusableChannel.channel.Cancel(consumer, noWait))
 }
 
 //Confirm wrap to use in reusable channel
@@ -74,7 +119,7 @@ func (reusableChannel *ReusableChannel) Confirm(noWait bool) error {
 		return err
 	}
 
-	return reusableChannel.channel.Confirm(noWait)
+	return reusableChannel.inspect(reusableChannel.channel.Confirm(noWait))
 }
 
 //ExchangeBind wrap to use in reusable channel
@@ -83,7 +128,7 @@ func (reusableChannel *ReusableChannel) ExchangeBind(destination, key, source st
 		return err
 	}
 
-	return reusableChannel.channel.ExchangeBind(destination, key, source, noWait, args)
+	return reusableChannel.inspect(reusableChannel.channel.ExchangeBind(destination, key, source, noWait, args))
 }
 
 //ExchangeDeclarePassive wrap to use in reusable channel
@@ -92,7 +137,7 @@ func (reusableChannel *ReusableChannel) ExchangeDeclarePassive(name, kind string
 		return err
 	}
 
-	return reusableChannel.channel.ExchangeDeclarePassive(name, kind, durable, autoDelete, internal, noWait, args)
+	return reusableChannel.inspect(reusableChannel.channel.ExchangeDeclarePassive(name, kind, durable, autoDelete, internal, noWait, args))
 }
 
 //ExchangeDelete wrap to use in reusable channel
@@ -101,7 +146,7 @@ func (reusableChannel *ReusableChannel) ExchangeDelete(name string, ifUnused, no
 		return err
 	}
 
-	return reusableChannel.channel.ExchangeDelete(name, ifUnused, noWait)
+	return reusableChannel.inspect(reusableChannel.channel.ExchangeDelete(name, ifUnused, noWait))
 }
 
 //ExchangeUnbind wrap to use in reusable channel
@@ -110,7 +155,7 @@ func (reusableChannel *ReusableChannel) ExchangeUnbind(destination, key, source
 		return err
 	}
 
-	return reusableChannel.channel.ExchangeUnbind(destination, key, source, noWait, args)
+	return reusableChannel.inspect(reusableChannel.channel.ExchangeUnbind(destination, key, source, noWait, args))
 }
 
 //Flow wrap to use in reusable channel
@@ -119,7 +164,7 @@ func (reusableChannel *ReusableChannel) Flow(active bool) error {
 		return err
 	}
 
-	return reusableChannel.channel.Flow(active)
+	return reusableChannel.inspect(reusableChannel.channel.Flow(active))
 }
 
 //Qos wrap to use in reusable channel
@@ -128,7 +173,7 @@ func (reusableChannel *ReusableChannel) Qos(prefetchCount, prefetchSize int, glo
 		return err
 	}
 
-	return reusableChannel.channel.Qos(prefetchCount, prefetchSize, global)
+	return reusableChannel.inspect(reusableChannel.channel.Qos(prefetchCount, prefetchSize, global))
 }
 
 //Recover wrap to use in reusable channel
@@ -137,7 +182,7 @@ func (reusableChannel *ReusableChannel) Recover(requeue bool) error {
 		return err
 	}
 
-	return reusableChannel.channel.Recover(requeue)
+	return reusableChannel.inspect(reusableChannel.channel.Recover(requeue))
 }
 
 //QueueUnbind wrap to use in reusable channel
@@ -146,7 +191,7 @@ func (reusableChannel *ReusableChannel) QueueUnbind(name, key, exchange string,
 		return err
 	}
 
-	return reusableChannel.channel.QueueUnbind(name, key, exchange, args)
+	return reusableChannel.inspect(reusableChannel.channel.QueueUnbind(name, key, exchange, args))
 }
 
 //Tx wrap to use in reusable channel
@@ -155,7 +200,7 @@ func (reusableChannel *ReusableChannel) Tx() error {
 		return err
 	}
 
-	return reusableChannel.channel.Tx()
+	return reusableChannel.inspect(reusableChannel.channel.Tx())
 }
 
 //TxCommit wrap to use in reusable channel
@@ -164,7 +209,7 @@ func (reusableChannel *ReusableChannel) TxCommit() error {
 		return err
 	}
 
-	return reusableChannel.channel.TxCommit()
+	return reusableChannel.inspect(reusableChannel.channel.TxCommit())
 }
 
 //TxRollback wrap to use in reusable channel
@@ -173,7 +218,7 @@ func (reusableChannel *ReusableChannel) TxRollback() error {
 		return err
 	}
 
-	return reusableChannel.channel.TxRollback()
+	return reusableChannel.inspect(reusableChannel.channel.TxRollback())
 }
 
 //Get wrap to use in reusable channel
@@ -182,7 +227,8 @@ func (reusableChannel *ReusableChannel) Get(queue string, autoAck bool) (msg amq
 		return amqp.Delivery{}, false, err
 	}
 
-	return reusableChannel.channel.Get(queue, autoAck)
+	msg, ok, err = reusableChannel.channel.Get(queue, autoAck)
+	return msg, ok, reusableChannel.inspect(err)
 }
 
 //Consume wrap to use in reusable channel
@@ -191,7 +237,8 @@ func (reusableChannel *ReusableChannel) Consume(queue, consumer string, autoAck,
 		return nil, err
 	}
 
-	return reusableChannel.channel.Consume(queue, consumer, autoAck, exclusive, noLocal, noWait, args)
+	deliveries, err := reusableChannel.channel.Consume(queue, consumer, autoAck, exclusive, noLocal, noWait, args)
+	return deliveries, reusableChannel.inspect(err)
 }
 
 //QueueInspect wrap to use in reusable channel
@@ -200,7 +247,8 @@ func (reusableChannel *ReusableChannel) QueueInspect(name string) (amqp.Queue, e
 		return amqp.Queue{}, err
 	}
 
-	return reusableChannel.channel.QueueInspect(name)
+	queue, err := reusableChannel.channel.QueueInspect(name)
+	return queue, reusableChannel.inspect(err)
 }
 
 //NotifyClose wrap to use in reusable channel
@@ -238,7 +286,8 @@ func (reusableChannel *ReusableChannel) QueueDeclare(name string, durable, autoD
 		return amqp.Queue{}, err
 	}
 
-	return reusableChannel.channel.QueueDeclare(name, durable, autoDelete, exclusive, noWait, args)
+	queue, err := reusableChannel.channel.QueueDeclare(name, durable, autoDelete, exclusive, noWait, args)
+	return queue, reusableChannel.inspect(err)
 }
 
 //NotifyFlow wrap to use in reusable channel
@@ -274,7 +323,8 @@ func (reusableChannel *ReusableChannel) QueueDeclarePassive(name string, durable
 		return amqp.Queue{}, err
 	}
 
-	return reusableChannel.channel.QueueDeclarePassive(name, durable, autoDelete, exclusive, noWait, args)
+	queue, err := reusableChannel.channel.QueueDeclarePassive(name, durable, autoDelete, exclusive, noWait, args)
+	return queue, reusableChannel.inspect(err)
 }
 
 //QueueDelete wrap to use in reusable channel
@@ -283,5 +333,6 @@ func (reusableChannel *ReusableChannel) QueueDelete(name string, ifUnused, ifEmp
 		return 0, err
 	}
 
-	return reusableChannel.channel.QueueDelete(name, ifUnused, ifEmpty, noWait)
+	purged, err := reusableChannel.channel.QueueDelete(name, ifUnused, ifEmpty, noWait)
+	return purged, reusableChannel.inspect(err)
 }